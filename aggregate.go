@@ -0,0 +1,63 @@
+package laplace
+
+import (
+    "sort"
+    "time"
+)
+
+// AggregateWorkloads aggregates load values for each unique timestamp across all workloads, indexing
+// them on up to workers goroutines (one per CPU if workers is 0). Callers that also need the peak or
+// its contributors should build a SeriesIndex once instead.
+func AggregateWorkloads(workloads []Workload, workers int) ([]SummedWorkload, error) {
+    return NewSeriesIndex(workloads, workers).Summed(), nil
+}
+
+// sortSummedWorkloads converts a map of summed workloads, keyed by UnixNano, to a sorted slice.
+func sortSummedWorkloads(summedWorkloadsMap map[int64]SummedWorkload) []SummedWorkload {
+    var summedWorkloads []SummedWorkload
+    for _, workload := range summedWorkloadsMap {
+        summedWorkloads = append(summedWorkloads, workload)
+    }
+    sort.Slice(summedWorkloads, func(i, j int) bool {
+        return summedWorkloads[i].Timestamp.Before(summedWorkloads[j].Timestamp)
+    })
+    return summedWorkloads
+}
+
+// PeakUsage is a timestamp at which the summed load of Dimension, or of every dimension together
+// when Dimension is TotalDimension, reaches TotalUsage.
+type PeakUsage struct {
+    Dimension string
+    Timestamp time.Time
+    TotalUsage float64
+}
+
+// FindPeakUsage returns the timestamp at which the workloads' load, summed across every dimension,
+// is highest. The workloads are indexed on up to workers goroutines as in AggregateWorkloads.
+func FindPeakUsage(workloads []Workload, workers int) PeakUsage {
+    return NewSeriesIndex(workloads, workers).Peak()
+}
+
+func GetLoadAtTimestamp(timedValues []TimedValue, timestamp time.Time) float64 {
+    for _, tv := range timedValues {
+        if tv.Timestamp == timestamp {
+            return tv.Value
+        }
+    }
+    return 0
+}
+
+type WorkloadContribution struct {
+    Name       string
+    LoadAtPeak float64
+}
+
+// findSummedPeak returns the timestamp of the summed series with the highest load across all
+// dimensions. The earliest timestamp wins a tie.
+func findSummedPeak(summed []SummedWorkload) PeakUsage {
+    peaks := FindTopPeaks(summed, TotalDimension, 1, 0)
+    if len(peaks) == 0 {
+        return PeakUsage{Dimension: TotalDimension}
+    }
+    return peaks[0]
+}
@@ -0,0 +1,343 @@
+package laplace
+
+import (
+    "encoding/csv"
+    "fmt"
+    "math"
+    "os"
+    "sort"
+    "strconv"
+    "time"
+)
+
+// Anomaly detection methods supported by DetectAnomalies.
+const (
+    // ZScore scores each value by its distance from the mean of the preceding window, in standard deviations.
+    ZScore = "zscore"
+    // MAD scores each value by its distance from the median of the preceding window, in median
+    // absolute deviations scaled to be comparable with standard deviations.
+    MAD = "mad"
+    // Seasonal scores the change in each value from the value one season earlier against the
+    // median and median absolute deviation of all such changes in the series.
+    Seasonal = "seasonal"
+)
+
+// Kinds of Anomaly.
+const (
+    PointAnomaly    = "point"
+    IntervalAnomaly = "interval"
+)
+
+// Scopes of Anomaly.
+const (
+    WorkloadScope = "workload"
+)
+
+// madScale makes a median absolute deviation comparable with a standard deviation for normally
+// distributed values.
+const madScale = 1.4826
+
+// minBaseline is the fewest values a rolling window needs before the values after it are scored.
+const minBaseline = 5
+
+// AnomalyOptions configures DetectAnomalies. Values scoring above Threshold are anomalous. Window
+// is the span of preceding values the ZScore and MAD methods compare against, and Season the
+// period the Seasonal method compares across.
+type AnomalyOptions struct {
+    Method    string
+    Window    time.Duration
+    Season    time.Duration
+    Threshold float64
+}
+
+// DefaultAnomalyOptions returns rolling z-score detection over the preceding hour with a
+// threshold of 3.
+func DefaultAnomalyOptions() AnomalyOptions {
+    return AnomalyOptions{Method: ZScore, Window: time.Hour, Season: 24 * time.Hour, Threshold: 3}
+}
+
+// validate checks the options.
+func (o AnomalyOptions) validate() error {
+    switch o.Method {
+    case ZScore, MAD, Seasonal:
+    default:
+        return fmt.Errorf("unknown anomaly method %q: want %s, %s or %s", o.Method, ZScore, MAD, Seasonal)
+    }
+    if o.Window <= 0 {
+        return fmt.Errorf("anomaly window must be positive, got %v", o.Window)
+    }
+    if o.Season <= 0 {
+        return fmt.Errorf("anomaly season must be positive, got %v", o.Season)
+    }
+    if o.Threshold <= 0 {
+        return fmt.Errorf("anomaly threshold must be positive, got %v", o.Threshold)
+    }
+    return nil
+}
+
+// Anomaly is a value, or a run of consecutive values, of one load series scoring above the
+// threshold. Timestamp, Value and Expected describe the most severe value of the run, and
+// Severity is its score: how many (robust) standard deviations it lies from the expected value.
+type Anomaly struct {
+    Scope     string
+    Workload  string
+    Dimension string
+    Kind      string
+    Start     time.Time
+    End       time.Time
+    Points    int
+    Timestamp time.Time
+    Value     float64
+    Expected  float64
+    Severity  float64
+}
+
+// scoredValue is a value of a series with the value expected there and its score.
+type scoredValue struct {
+    TimedValue
+    expected float64
+    score    float64
+    scored   bool
+}
+
+// DetectAnomalies finds the anomalies in every load series of the workloads on up to workers
+// goroutines (one per CPU if workers is 0). They are returned in workload order, then by
+// dimension and time.
+func DetectAnomalies(workloads []Workload, opts AnomalyOptions, workers int) ([]Anomaly, error) {
+    if err := opts.validate(); err != nil {
+        return nil, err
+    }
+    perWorkload := make([][]Anomaly, len(workloads))
+    parallelFor(len(workloads), workers, func(i int) {
+        workload := workloads[i]
+        for _, dimension := range workload.Dimensions() {
+            perWorkload[i] = append(perWorkload[i], detectSeriesAnomalies(WorkloadScope, workload.Name, dimension, workload.Loads[dimension], opts)...)
+        }
+    })
+
+    var anomalies []Anomaly
+    for _, workloadAnomalies := range perWorkload {
+        anomalies = append(anomalies, workloadAnomalies...)
+    }
+    return anomalies, nil
+}
+
+// DetectSummedAnomalies finds the anomalies in each dimension of the summed series returned by
+// AggregateWorkloads. They are reported with the FleetScope and no workload.
+func DetectSummedAnomalies(summed []SummedWorkload, opts AnomalyOptions) ([]Anomaly, error) {
+    if err := opts.validate(); err != nil {
+        return nil, err
+    }
+    series := make(map[string][]TimedValue)
+    for _, summedWorkload := range summed {
+        for dimension, total := range summedWorkload.Totals {
+            series[dimension] = append(series[dimension], TimedValue{Timestamp: summedWorkload.Timestamp, Value: total})
+        }
+    }
+    dimensions := make([]string, 0, len(series))
+    for dimension := range series {
+        dimensions = append(dimensions, dimension)
+    }
+    sort.Strings(dimensions)
+
+    var anomalies []Anomaly
+    for _, dimension := range dimensions {
+        anomalies = append(anomalies, detectSeriesAnomalies(FleetScope, "", dimension, series[dimension], opts)...)
+    }
+    return anomalies, nil
+}
+
+// detectSeriesAnomalies scores one series and groups consecutive anomalous values into anomalies.
+func detectSeriesAnomalies(scope, workload, dimension string, series []TimedValue, opts AnomalyOptions) []Anomaly {
+    sorted := append([]TimedValue(nil), series...)
+    sort.SliceStable(sorted, func(i, j int) bool {
+        return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+    })
+
+    var scored []scoredValue
+    switch opts.Method {
+    case ZScore:
+        scored = scoreRolling(sorted, opts.Window, rollingZScore)
+    case MAD:
+        scored = scoreRolling(sorted, opts.Window, rollingMAD)
+    case Seasonal:
+        scored = scoreSeasonal(sorted, opts.Season)
+    }
+
+    var anomalies []Anomaly
+    var current *Anomaly
+    for _, value := range scored {
+        if !value.scored || math.Abs(value.score) <= opts.Threshold {
+            current = nil
+            continue
+        }
+        if current == nil {
+            anomalies = append(anomalies, Anomaly{
+                Scope:     scope,
+                Workload:  workload,
+                Dimension: dimension,
+                Kind:      PointAnomaly,
+                Start:     value.Timestamp,
+            })
+            current = &anomalies[len(anomalies)-1]
+        }
+        current.End = value.Timestamp
+        current.Points++
+        if current.Points > 1 {
+            current.Kind = IntervalAnomaly
+        }
+        if severity := math.Abs(value.score); severity > current.Severity {
+            current.Timestamp = value.Timestamp
+            current.Value = value.Value
+            current.Expected = value.expected
+            current.Severity = severity
+        }
+    }
+    return anomalies
+}
+
+// scoreRolling scores each value against the values in the window before it using score, which
+// returns the expected value and the spread of the window. Values with fewer than minBaseline
+// values before them in the window, or a window without spread, are left unscored.
+func scoreRolling(sorted []TimedValue, window time.Duration, score func(baseline []TimedValue) (float64, float64)) []scoredValue {
+    scored := make([]scoredValue, len(sorted))
+    start := 0
+    for i, timedValue := range sorted {
+        scored[i].TimedValue = timedValue
+        for start < i && timedValue.Timestamp.Sub(sorted[start].Timestamp) > window {
+            start++
+        }
+        baseline := sorted[start:i]
+        if len(baseline) < minBaseline {
+            continue
+        }
+        expected, spread := score(baseline)
+        if spread == 0 {
+            continue
+        }
+        scored[i].expected = expected
+        scored[i].score = (timedValue.Value - expected) / spread
+        scored[i].scored = true
+    }
+    return scored
+}
+
+// rollingZScore returns the mean and standard deviation of the baseline.
+func rollingZScore(baseline []TimedValue) (float64, float64) {
+    values := timedValues(baseline)
+    return mean(values), calculateStandardDeviation(values)
+}
+
+// rollingMAD returns the median and the scaled median absolute deviation of the baseline.
+func rollingMAD(baseline []TimedValue) (float64, float64) {
+    median, deviation := medianAbsoluteDeviation(timedValues(baseline))
+    return median, deviation
+}
+
+// scoreSeasonal scores the change of each value from the value exactly one season earlier
+// against the median and scaled median absolute deviation of every such change.
+func scoreSeasonal(sorted []TimedValue, season time.Duration) []scoredValue {
+    byTimestamp := make(map[int64]float64, len(sorted)) // by UnixNano
+    for _, timedValue := range sorted {
+        byTimestamp[timedValue.Timestamp.UnixNano()] = timedValue.Value
+    }
+
+    scored := make([]scoredValue, len(sorted))
+    var residuals []float64
+    for i, timedValue := range sorted {
+        scored[i].TimedValue = timedValue
+        previous, exists := byTimestamp[timedValue.Timestamp.Add(-season).UnixNano()]
+        if !exists {
+            continue
+        }
+        scored[i].expected = previous
+        scored[i].scored = true
+        residuals = append(residuals, timedValue.Value-previous)
+    }
+
+    median, deviation := medianAbsoluteDeviation(residuals)
+    for i := range scored {
+        if !scored[i].scored {
+            continue
+        }
+        if deviation == 0 {
+            scored[i].scored = false
+            continue
+        }
+        residual := scored[i].Value - scored[i].expected
+        scored[i].expected += median
+        scored[i].score = (residual - median) / deviation
+    }
+    return scored
+}
+
+// medianAbsoluteDeviation returns the median of values and their median absolute deviation from
+// it, scaled by madScale.
+func medianAbsoluteDeviation(values []float64) (float64, float64) {
+    if len(values) == 0 {
+        return 0, 0
+    }
+    median := medianOf(values)
+    deviations := make([]float64, len(values))
+    for i, value := range values {
+        deviations[i] = math.Abs(value - median)
+    }
+    return median, medianOf(deviations) * madScale
+}
+
+// medianOf returns the median of values without reordering them.
+func medianOf(values []float64) float64 {
+    sorted := append([]float64(nil), values...)
+    sort.Float64s(sorted)
+    middle := len(sorted) / 2
+    if len(sorted)%2 == 0 {
+        return (sorted[middle-1] + sorted[middle]) / 2
+    }
+    return sorted[middle]
+}
+
+func timedValues(series []TimedValue) []float64 {
+    values := make([]float64, len(series))
+    for i, timedValue := range series {
+        values[i] = timedValue.Value
+    }
+    return values
+}
+
+// WriteAnomaliesToFile writes one row per anomaly.
+func WriteAnomaliesToFile(anomalies []Anomaly, outputFile string) error {
+    file, err := os.Create(outputFile)
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+
+    writer := csv.NewWriter(file)
+    defer writer.Flush()
+
+    header := []string{"Scope", "Workload", "Dimension", "Kind", "Start", "End", "Points", "Timestamp", "Value", "Expected", "Severity"}
+    if err := writer.Write(header); err != nil {
+        return err
+    }
+
+    for _, anomaly := range anomalies {
+        record := []string{
+            anomaly.Scope,
+            anomaly.Workload,
+            anomaly.Dimension,
+            anomaly.Kind,
+            anomaly.Start.Format(time.RFC3339),
+            anomaly.End.Format(time.RFC3339),
+            strconv.Itoa(anomaly.Points),
+            anomaly.Timestamp.Format(time.RFC3339),
+            fmt.Sprintf("%.2f", anomaly.Value),
+            fmt.Sprintf("%.2f", anomaly.Expected),
+            fmt.Sprintf("%.2f", anomaly.Severity),
+        }
+        if err := writer.Write(record); err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
@@ -0,0 +1,440 @@
+package laplace
+
+import (
+    "encoding/csv"
+    "encoding/json"
+    "fmt"
+    "os"
+    "path"
+    "sort"
+    "strings"
+    "time"
+)
+
+// DefaultWarningRatio is the fraction of a capacity limit at which usage starts raising warnings
+// when no warning ratio is configured.
+const DefaultWarningRatio = 0.8
+
+// FleetScope names the capacity limits that apply to every workload together.
+const FleetScope = "fleet"
+
+// Capacity levels of a CapacityEvent.
+const (
+    LevelWarning = "warning"
+    LevelBreach  = "breach"
+)
+
+// CapacityLimits declares the capacity of load dimensions for the whole fleet and for groups of
+// workloads. A limit may be set on TotalDimension to cap the load summed across every dimension.
+// Usage above WarningRatio times a limit raises a warning, and usage above the limit a breach.
+type CapacityLimits struct {
+    WarningRatio float64            `json:"warningRatio"`
+    Limits       map[string]float64 `json:"limits"`
+    Groups       []CapacityGroup    `json:"groups"`
+}
+
+// CapacityGroup is a set of workloads with capacity limits of its own, such as the workloads of
+// one cluster. Members are listed by name, matched by a path.Match pattern, or both. A zero
+// WarningRatio inherits the fleet's.
+type CapacityGroup struct {
+    Name         string             `json:"name"`
+    Workloads    []string           `json:"workloads"`
+    Pattern      string             `json:"pattern"`
+    WarningRatio float64            `json:"warningRatio"`
+    Limits       map[string]float64 `json:"limits"`
+}
+
+// CapacityHeadroom is how close one dimension of a scope came to its limit over the timeline.
+type CapacityHeadroom struct {
+    Scope         string
+    Dimension     string
+    Limit         float64
+    Peak          PeakUsage
+    Headroom      float64 // Limit minus the peak usage; negative when the limit was breached.
+    HeadroomRatio float64 // Headroom as a fraction of Limit.
+    Warnings      int
+    Breaches      int
+    BreachTime    time.Duration
+}
+
+// CapacityEvent is a run of consecutive timestamps at which one dimension of a scope stayed at the
+// same capacity level. Duration runs from Start to the first timestamp after the event, or to End
+// when the event lasts until the end of the timeline. Responsible holds the largest contributors
+// at the event's peak whose combined load covers the usage above Threshold.
+type CapacityEvent struct {
+    Scope        string
+    Dimension    string
+    Level        string
+    Threshold    float64
+    Limit        float64
+    Start        time.Time
+    End          time.Time
+    Duration     time.Duration
+    Peak         PeakUsage
+    Contributors []WorkloadContribution
+    Responsible  []WorkloadContribution
+}
+
+// CapacityReport is the outcome of AnalyzeCapacity.
+type CapacityReport struct {
+    Headroom []CapacityHeadroom
+    Events   []CapacityEvent
+}
+
+// LoadCapacityLimits loads capacity limits from a JSON file shaped as
+// {"warningRatio": 0.8, "limits": {"cpu": 4000}, "groups": [{"name": "web", "pattern": "web-*", "limits": {"cpu": 1000}}]}.
+func LoadCapacityLimits(filename string) (CapacityLimits, error) {
+    var limits CapacityLimits
+
+    file, err := os.Open(filename)
+    if err != nil {
+        return limits, err
+    }
+    defer file.Close()
+
+    if err := json.NewDecoder(file).Decode(&limits); err != nil {
+        return limits, fmt.Errorf("decoding capacity %s: %w", filename, err)
+    }
+    if err := limits.validate(); err != nil {
+        return limits, fmt.Errorf("capacity %s: %w", filename, err)
+    }
+    return limits, nil
+}
+
+// validate checks the limits and groups.
+func (c CapacityLimits) validate() error {
+    if err := validateCapacityScope(FleetScope, c.WarningRatio, c.Limits); err != nil {
+        return err
+    }
+    names := map[string]bool{FleetScope: true}
+    for _, group := range c.Groups {
+        if group.Name == "" {
+            return fmt.Errorf("capacity group without a name")
+        }
+        if names[group.Name] {
+            return fmt.Errorf("duplicate capacity group %q", group.Name)
+        }
+        names[group.Name] = true
+        if len(group.Workloads) == 0 && group.Pattern == "" {
+            return fmt.Errorf("capacity group %q has no workloads or pattern", group.Name)
+        }
+        if _, err := path.Match(group.Pattern, ""); err != nil {
+            return fmt.Errorf("capacity group %q: %w", group.Name, err)
+        }
+        if err := validateCapacityScope(group.Name, group.WarningRatio, group.Limits); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func validateCapacityScope(scope string, warningRatio float64, limits map[string]float64) error {
+    if warningRatio < 0 || warningRatio > 1 {
+        return fmt.Errorf("%s: warning ratio must be between 0 and 1, got %v", scope, warningRatio)
+    }
+    for dimension, limit := range limits {
+        if limit <= 0 {
+            return fmt.Errorf("%s: limit for %s must be positive, got %v", scope, dimension, limit)
+        }
+    }
+    return nil
+}
+
+// members returns the workloads belonging to the group, in workload order.
+func (g CapacityGroup) members(workloads []Workload) []Workload {
+    names := make(map[string]bool, len(g.Workloads))
+    for _, name := range g.Workloads {
+        names[name] = true
+    }
+
+    var members []Workload
+    for _, workload := range workloads {
+        matched := false
+        if g.Pattern != "" {
+            matched, _ = path.Match(g.Pattern, workload.Name)
+        }
+        if names[workload.Name] || matched {
+            members = append(members, workload)
+        }
+    }
+    return members
+}
+
+// capacityScope is the fleet or one group with the limits that apply to it.
+type capacityScope struct {
+    name         string
+    workloads    []Workload
+    limits       map[string]float64
+    warningRatio float64
+}
+
+// AnalyzeCapacity compares the summed load timeline of the fleet and of each group against their
+// limits, indexing the workloads on up to workers goroutines (one per CPU if workers is 0). It
+// returns the headroom left in each limited dimension and every warning and breach, in scope,
+// dimension and time order. A limit on a dimension no workload reports, such as a misspelled one,
+// is an error rather than a limit with full headroom.
+func AnalyzeCapacity(workloads []Workload, limits CapacityLimits, workers int) (CapacityReport, error) {
+    if err := limits.checkDimensions(CollectDimensions(workloads)); err != nil {
+        return CapacityReport{}, err
+    }
+
+    fleetRatio := limits.WarningRatio
+    if fleetRatio == 0 {
+        fleetRatio = DefaultWarningRatio
+    }
+    scopes := []capacityScope{{name: FleetScope, workloads: workloads, limits: limits.Limits, warningRatio: fleetRatio}}
+    for _, group := range limits.Groups {
+        warningRatio := group.WarningRatio
+        if warningRatio == 0 {
+            warningRatio = fleetRatio
+        }
+        scopes = append(scopes, capacityScope{name: group.Name, workloads: group.members(workloads), limits: group.Limits, warningRatio: warningRatio})
+    }
+
+    var report CapacityReport
+    for _, scope := range scopes {
+        if len(scope.limits) == 0 {
+            continue
+        }
+        index := NewSeriesIndex(scope.workloads, workers)
+        summed := index.Summed()
+
+        dimensions := make([]string, 0, len(scope.limits))
+        for dimension := range scope.limits {
+            dimensions = append(dimensions, dimension)
+        }
+        sort.Strings(dimensions)
+
+        for _, dimension := range dimensions {
+            headroom, events := analyzeCapacityDimension(index, summed, scope, dimension)
+            report.Headroom = append(report.Headroom, headroom)
+            report.Events = append(report.Events, events...)
+        }
+    }
+    return report, nil
+}
+
+// checkDimensions returns an error for the first limit, in scope and dimension order, on a
+// dimension other than TotalDimension that isn't among the sorted dimensions.
+func (c CapacityLimits) checkDimensions(dimensions []string) error {
+    scopes := []capacityScope{{name: FleetScope, limits: c.Limits}}
+    for _, group := range c.Groups {
+        scopes = append(scopes, capacityScope{name: group.Name, limits: group.Limits})
+    }
+    for _, scope := range scopes {
+        limited := make([]string, 0, len(scope.limits))
+        for dimension := range scope.limits {
+            limited = append(limited, dimension)
+        }
+        sort.Strings(limited)
+        for _, dimension := range limited {
+            i := sort.SearchStrings(dimensions, dimension)
+            if dimension != TotalDimension && (i == len(dimensions) || dimensions[i] != dimension) {
+                return fmt.Errorf("%s: limit on %s, which no workload reports", scope.name, dimension)
+            }
+        }
+    }
+    return nil
+}
+
+// analyzeCapacityDimension walks the summed timeline of one dimension of a scope, grouping
+// consecutive timestamps at the same capacity level into events.
+func analyzeCapacityDimension(index *SeriesIndex, summed []SummedWorkload, scope capacityScope, dimension string) (CapacityHeadroom, []CapacityEvent) {
+    limit := scope.limits[dimension]
+    warning := limit * scope.warningRatio
+    headroom := CapacityHeadroom{Scope: scope.name, Dimension: dimension, Limit: limit, Peak: PeakUsage{Dimension: dimension}}
+
+    levelOf := func(usage float64) string {
+        switch {
+        case usage > limit:
+            return LevelBreach
+        case usage > warning:
+            return LevelWarning
+        default:
+            return ""
+        }
+    }
+
+    var events []CapacityEvent
+    var current *CapacityEvent
+    closeEvent := func(next *time.Time) {
+        if current == nil {
+            return
+        }
+        if next != nil {
+            current.Duration = next.Sub(current.Start)
+        } else {
+            current.Duration = current.End.Sub(current.Start)
+        }
+        events = append(events, *current)
+        current = nil
+    }
+
+    for _, summedWorkload := range summed {
+        timestamp := summedWorkload.Timestamp
+        usage := summedUsage(summedWorkload, dimension)
+        if usage > headroom.Peak.TotalUsage || headroom.Peak.Timestamp.IsZero() {
+            headroom.Peak = PeakUsage{Dimension: dimension, Timestamp: timestamp, TotalUsage: usage}
+        }
+
+        level := levelOf(usage)
+        if current != nil && current.Level != level {
+            closeEvent(&timestamp)
+        }
+        if level == "" {
+            continue
+        }
+        if current == nil {
+            threshold := warning
+            if level == LevelBreach {
+                threshold = limit
+            }
+            current = &CapacityEvent{
+                Scope:     scope.name,
+                Dimension: dimension,
+                Level:     level,
+                Threshold: threshold,
+                Limit:     limit,
+                Start:     timestamp,
+            }
+        }
+        current.End = timestamp
+        if usage > current.Peak.TotalUsage || current.Peak.Timestamp.IsZero() {
+            current.Peak = PeakUsage{Dimension: dimension, Timestamp: timestamp, TotalUsage: usage}
+        }
+    }
+    closeEvent(nil)
+
+    for i := range events {
+        event := &events[i]
+        event.Contributors = sortContributions(index.PeakContributions(event.Peak))
+        event.Responsible = responsibleContributors(event.Contributors, event.Peak.TotalUsage-event.Threshold)
+        if event.Level == LevelBreach {
+            headroom.Breaches++
+            headroom.BreachTime += event.Duration
+        } else {
+            headroom.Warnings++
+        }
+    }
+    headroom.Headroom = limit - headroom.Peak.TotalUsage
+    headroom.HeadroomRatio = headroom.Headroom / limit
+    return headroom, events
+}
+
+// responsibleContributors returns the largest of the sorted contributors whose combined load
+// covers the excess.
+func responsibleContributors(contributors []WorkloadContribution, excess float64) []WorkloadContribution {
+    var covered float64
+    for i, contributor := range contributors {
+        if covered >= excess || contributor.LoadAtPeak <= 0 {
+            return contributors[:i]
+        }
+        covered += contributor.LoadAtPeak
+    }
+    return contributors
+}
+
+// PrintCapacityReport prints the headroom of each limited dimension and every warning and breach
+// with the workloads responsible for it.
+func PrintCapacityReport(report CapacityReport) {
+    fmt.Println("\nCapacity Headroom:")
+    for _, headroom := range report.Headroom {
+        fmt.Printf("  %s %s: limit %.2f, peak %.2f at %v, headroom %.2f (%.2f%%), %d warnings, %d breaches lasting %v\n",
+            headroom.Scope, headroom.Dimension, headroom.Limit, headroom.Peak.TotalUsage, headroom.Peak.Timestamp,
+            headroom.Headroom, headroom.HeadroomRatio*100, headroom.Warnings, headroom.Breaches, headroom.BreachTime)
+    }
+
+    if len(report.Events) == 0 {
+        fmt.Println("\nNo Capacity Warnings or Breaches")
+        return
+    }
+    fmt.Println("\nCapacity Warnings and Breaches:")
+    for _, event := range report.Events {
+        fmt.Printf("  %s %s %s from %v to %v (%v): peak %.2f at %v, threshold %.2f\n",
+            event.Scope, event.Dimension, event.Level, event.Start, event.End, event.Duration,
+            event.Peak.TotalUsage, event.Peak.Timestamp, event.Threshold)
+        for _, contributor := range event.Responsible {
+            fmt.Printf("    Workload: %s, Load at Peak: %.2f\n", contributor.Name, contributor.LoadAtPeak)
+        }
+    }
+}
+
+// WriteCapacityHeadroomToFile writes the headroom of each limited dimension of each scope.
+func WriteCapacityHeadroomToFile(report CapacityReport, outputFile string) error {
+    file, err := os.Create(outputFile)
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+
+    writer := csv.NewWriter(file)
+    defer writer.Flush()
+
+    header := []string{"Scope", "Dimension", "Limit", "PeakTimestamp", "PeakUsage", "Headroom", "HeadroomPercent", "Warnings", "Breaches", "BreachSeconds"}
+    if err := writer.Write(header); err != nil {
+        return err
+    }
+
+    for _, headroom := range report.Headroom {
+        record := []string{
+            headroom.Scope,
+            headroom.Dimension,
+            fmt.Sprintf("%.2f", headroom.Limit),
+            headroom.Peak.Timestamp.Format(time.RFC3339),
+            fmt.Sprintf("%.2f", headroom.Peak.TotalUsage),
+            fmt.Sprintf("%.2f", headroom.Headroom),
+            fmt.Sprintf("%.2f", headroom.HeadroomRatio*100),
+            fmt.Sprint(headroom.Warnings),
+            fmt.Sprint(headroom.Breaches),
+            fmt.Sprintf("%.0f", headroom.BreachTime.Seconds()),
+        }
+        if err := writer.Write(record); err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// WriteCapacityEventsToFile writes one row per warning or breach. Responsible lists the
+// workloads responsible for the event as name:load pairs separated by semicolons.
+func WriteCapacityEventsToFile(report CapacityReport, outputFile string) error {
+    file, err := os.Create(outputFile)
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+
+    writer := csv.NewWriter(file)
+    defer writer.Flush()
+
+    header := []string{"Scope", "Dimension", "Level", "Threshold", "Limit", "Start", "End", "DurationSeconds", "PeakTimestamp", "PeakUsage", "Responsible"}
+    if err := writer.Write(header); err != nil {
+        return err
+    }
+
+    for _, event := range report.Events {
+        responsible := make([]string, len(event.Responsible))
+        for i, contributor := range event.Responsible {
+            responsible[i] = fmt.Sprintf("%s:%.2f", contributor.Name, contributor.LoadAtPeak)
+        }
+        record := []string{
+            event.Scope,
+            event.Dimension,
+            event.Level,
+            fmt.Sprintf("%.2f", event.Threshold),
+            fmt.Sprintf("%.2f", event.Limit),
+            event.Start.Format(time.RFC3339),
+            event.End.Format(time.RFC3339),
+            fmt.Sprintf("%.0f", event.Duration.Seconds()),
+            event.Peak.Timestamp.Format(time.RFC3339),
+            fmt.Sprintf("%.2f", event.Peak.TotalUsage),
+            strings.Join(responsible, ";"),
+        }
+        if err := writer.Write(record); err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
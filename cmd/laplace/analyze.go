@@ -0,0 +1,450 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "log"
+    "os"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/codyshoward/laplace"
+)
+
+// runAnalyze loads the workload files named by --in, prints the workload statistics, peak
+// contributors and volatility categories, and writes the CSV reports.
+func runAnalyze(args []string) error {
+    flags := flag.NewFlagSet("analyze", flag.ContinueOnError)
+    opts := addWorkloadFlags(flags)
+    analysis := addAnalysisFlags(flags)
+    flagRatio := flags.Float64("flag-ratio", laplace.DefaultEfficiencyFlagRatio, "flag workloads whose relative value divided by relative cost is below this")
+    stream := flags.Bool("stream", false, "decode the workload files incrementally instead of loading them into memory")
+    peaks := addPeakFlags(flags)
+    tiers := addTierFlags(flags)
+    capacityFile := flags.String("capacity", "", "JSON file of capacity limits per load dimension to report headroom and breaches against")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if err := analysis.validate(); err != nil {
+        return err
+    }
+    ranking, err := tiers.ranking()
+    if err != nil {
+        return err
+    }
+
+    reports, err := opts.reports()
+    if err != nil {
+        return err
+    }
+    pricing, err := analysis.pricing()
+    if err != nil {
+        return err
+    }
+    if *stream {
+        if *capacityFile != "" {
+            return fmt.Errorf("--capacity can't be combined with --stream")
+        }
+        if opts.gaps.Strategy != laplace.GapMissing {
+            return fmt.Errorf("--fill can't be combined with --stream")
+        }
+        if opts.step != 0 {
+            return fmt.Errorf("--resample can't be combined with --stream")
+        }
+        return analyzeStream(opts, analysis, peaks, ranking, pricing, reports, *flagRatio)
+    }
+
+    var limits *laplace.CapacityLimits
+    if *capacityFile != "" {
+        loaded, err := laplace.LoadCapacityLimits(*capacityFile)
+        if err != nil {
+            return err
+        }
+        limits = &loaded
+    }
+
+    data, err := opts.load()
+    if err != nil {
+        return err
+    }
+    // Calculate various statistics for the loaded workload data.
+    _, totalCost, _ := laplace.CalculateWorkloadStats(data, analysis.interval, pricing, opts.workers)
+
+    // Index the workloads once to aggregate them, find the peaks and each workload's share of them.
+    index := laplace.NewSeriesIndex(data.Workloads, opts.workers)
+    summed := index.Summed()
+    peakUsages := peaks.find(summed)
+    contributions := make([][]laplace.WorkloadContribution, len(peakUsages))
+    for i, peakUsage := range peakUsages {
+        contributions[i] = index.PeakContributions(peakUsage)
+    }
+    result := analysisResult{
+        data:      data,
+        summed:    summed,
+        totalCost: totalCost,
+        peaks:     laplace.AttributePeaks(peakUsages, contributions),
+    }
+    if limits != nil {
+        // Compare the summed timelines of the fleet and of each group against their limits.
+        capacity, err := laplace.AnalyzeCapacity(data.Workloads, *limits, opts.workers)
+        if err != nil {
+            return err
+        }
+        result.capacity = &capacity
+    }
+    if err := reportAnalysis(result, pricing, analysis, ranking, reports, *flagRatio); err != nil {
+        return err
+    }
+    if opts.gaps.Strategy != laplace.GapMissing {
+        // Report how many values were synthesized in each workload's dimensions.
+        if err := laplace.WriteSynthesizedPointsToFile(data, reports.gaps()); err != nil {
+            return err
+        }
+    }
+
+    // Write workload volatility intervals to a CSV file.
+    return laplace.WriteWorkloadIntervalVolatilityToFile(data, reports.intervalChanges(), analysis.interval, opts.workers)
+}
+
+// analyzeStream is runAnalyze for --stream. Each file is decoded token by token into a
+// StreamAnalyzer, so only one workload's loads are held at a time, and the files are read a second
+// time to attribute the peaks to the workloads.
+func analyzeStream(opts *workloadOptions, analysis *analysisOptions, peaks *peakOptions, ranking volatilityRanking, pricing laplace.Pricing, reports reportFiles, flagRatio float64) error {
+    files, err := opts.files()
+    if err != nil {
+        return err
+    }
+    for _, file := range files {
+        if format := laplace.FileFormat(file, opts.format); format != laplace.FormatJSON {
+            return fmt.Errorf("--stream only reads %s files, but %s is %s", laplace.FormatJSON, file, format)
+        }
+    }
+
+    changesFile, err := os.Create(reports.intervalChanges())
+    if err != nil {
+        return err
+    }
+    defer changesFile.Close()
+    changes, err := laplace.NewIntervalChangeWriter(changesFile, analysis.interval)
+    if err != nil {
+        return err
+    }
+
+    analyzer := laplace.NewStreamAnalyzer(analysis.interval, pricing)
+    analyzer.IntervalChanges = changes
+    failed := 0
+    for _, file := range files {
+        if err := analyzer.ConsumeFile(file); err != nil {
+            log.Printf("Error streaming data from file %s: %v", file, err)
+            failed++
+        }
+    }
+    if failed > 0 {
+        return fmt.Errorf("%d of %d files failed to load", failed, len(files))
+    }
+    if err := changes.Flush(); err != nil {
+        return err
+    }
+    streamed := analyzer.Finish()
+
+    peakUsages := peaks.find(streamed.Summed)
+    contributions := make([][]laplace.WorkloadContribution, len(peakUsages))
+    for _, file := range files {
+        fileContributions, err := streamContributionsAt(file, peakUsages)
+        if err != nil {
+            log.Printf("Error streaming data from file %s: %v", file, err)
+        }
+        for i := range fileContributions {
+            contributions[i] = append(contributions[i], fileContributions[i]...)
+        }
+    }
+
+    result := analysisResult{
+        data:      streamed.Data,
+        summed:    streamed.Summed,
+        totalCost: streamed.TotalCost,
+        peaks:     laplace.AttributePeaks(peakUsages, contributions),
+    }
+    return reportAnalysis(result, pricing, analysis, ranking, reports, flagRatio)
+}
+
+// streamContributionsAt returns the load of each workload in file at each of the peaks.
+func streamContributionsAt(file string, peaks []laplace.PeakUsage) ([][]laplace.WorkloadContribution, error) {
+    f, err := os.Open(file)
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+
+    return laplace.StreamContributionsAt(f, peaks)
+}
+
+// peakOptions are the flags selecting the peaks analyze attributes to workloads.
+type peakOptions struct {
+    count int
+    gap   time.Duration
+}
+
+// addPeakFlags registers the peak flags on flags.
+func addPeakFlags(flags *flag.FlagSet) *peakOptions {
+    opts := &peakOptions{}
+    flags.IntVar(&opts.count, "peaks", 1, "number of peaks to report, overall and per dimension")
+    flags.DurationVar(&opts.gap, "peak-gap", 0, "minimum time between two reported peaks of the same dimension, e.g. 1h")
+    return opts
+}
+
+// find returns the top peaks of the load summed across every dimension, followed by the top peaks
+// of each dimension.
+func (o *peakOptions) find(summed []laplace.SummedWorkload) []laplace.PeakUsage {
+    peaks := laplace.FindTopPeaks(summed, laplace.TotalDimension, o.count, o.gap)
+    for _, dimension := range summedDimensions(summed) {
+        peaks = append(peaks, laplace.FindTopPeaks(summed, dimension, o.count, o.gap)...)
+    }
+    return peaks
+}
+
+// tierFlags are the flags selecting how analyze ranks the workloads by volatility and splits them
+// into volatility tiers.
+type tierFlags struct {
+    metric     string
+    mode       string
+    thresholds string
+    quantiles  string
+    tiers      int
+    labels     string
+    labelsSet  bool
+}
+
+// addTierFlags registers the volatility tiering flags on flags.
+func addTierFlags(flags *flag.FlagSet) *tierFlags {
+    defaults := laplace.DefaultTierOptions()
+    opts := &tierFlags{}
+    flags.StringVar(&opts.metric, "volatility-metric", laplace.StdDevMetric, "volatility workloads are ranked and tiered by: stddev, cv, peak-to-mean or iqr-to-mean")
+    flags.StringVar(&opts.mode, "tier-mode", defaults.Mode, "how workloads are split into volatility tiers: thresholds, quantiles, kmeans or jenks")
+    flags.StringVar(&opts.thresholds, "tier-thresholds", "", "comma-separated ascending volatility thresholds of --tier-mode thresholds, e.g. 10,50")
+    flags.StringVar(&opts.quantiles, "tier-quantiles", "", "comma-separated ascending cut points of --tier-mode quantiles (default thirds)")
+    flags.IntVar(&opts.tiers, "tiers", defaults.Tiers, "number of tiers of --tier-mode kmeans and jenks")
+    flags.Func("tier-labels", "comma-separated tier names, lowest first (default Low,Medium,High for three tiers)", func(value string) error {
+        opts.labels, opts.labelsSet = value, true
+        return nil
+    })
+    return opts
+}
+
+// volatilityRanking is the volatility metric and tiering options selected by the tier flags.
+type volatilityRanking struct {
+    metric string
+    tiers  laplace.TierOptions
+}
+
+// ranking converts the parsed flags to a volatility metric and tiering options.
+func (f *tierFlags) ranking() (volatilityRanking, error) {
+    if err := laplace.ValidateVolatilityMetric(f.metric); err != nil {
+        return volatilityRanking{}, err
+    }
+    opts, err := f.options()
+    return volatilityRanking{metric: f.metric, tiers: opts}, err
+}
+
+// options converts the parsed tiering flags to tiering options.
+func (f *tierFlags) options() (laplace.TierOptions, error) {
+    opts := laplace.DefaultTierOptions()
+    opts.Mode = f.mode
+    opts.Tiers = f.tiers
+    var err error
+    if opts.Thresholds, err = parseFloatList(f.thresholds); err != nil {
+        return opts, fmt.Errorf("--tier-thresholds: %w", err)
+    }
+    if f.quantiles != "" {
+        if opts.Quantiles, err = parseFloatList(f.quantiles); err != nil {
+            return opts, fmt.Errorf("--tier-quantiles: %w", err)
+        }
+    }
+    if f.labelsSet {
+        if opts.Labels = parseList(f.labels); len(opts.Labels) == 0 {
+            return opts, fmt.Errorf("--tier-labels must name at least one tier")
+        }
+    }
+    return opts, opts.Validate()
+}
+
+// parseList splits a comma-separated list, dropping blank entries.
+func parseList(input string) []string {
+    var values []string
+    for _, field := range strings.Split(input, ",") {
+        if field = strings.TrimSpace(field); field != "" {
+            values = append(values, field)
+        }
+    }
+    return values
+}
+
+// parseFloatList parses a comma-separated list of numbers.
+func parseFloatList(input string) ([]float64, error) {
+    var values []float64
+    for _, field := range strings.Split(input, ",") {
+        field = strings.TrimSpace(field)
+        if field == "" {
+            continue
+        }
+        value, err := strconv.ParseFloat(field, 64)
+        if err != nil {
+            return nil, err
+        }
+        values = append(values, value)
+    }
+    return values, nil
+}
+
+// analysisResult is what runAnalyze calculated, whether from loaded or streamed workloads.
+type analysisResult struct {
+    data      *laplace.Data
+    summed    []laplace.SummedWorkload
+    totalCost float64
+    peaks     []laplace.PeakAttribution
+    capacity  *laplace.CapacityReport
+}
+
+// reportAnalysis prints the workload statistics, efficiency ranking, peak contributors and
+// volatility categories, and writes every report except the interval changes.
+func reportAnalysis(result analysisResult, pricing laplace.Pricing, analysis *analysisOptions, ranking volatilityRanking, reports reportFiles, flagRatio float64) error {
+    data := result.data
+    laplace.PrintWorkloadStats(*data)
+    fmt.Printf("Total Cost of All Workloads: %.2f %s\n", result.totalCost, pricing.Currency)
+
+    // Report the percentiles of each workload and of the fleet's summed load.
+    fleetPercentiles := laplace.SummedPercentiles(result.summed)
+    laplace.PrintSummedPercentiles(fleetPercentiles)
+    if err := laplace.WritePercentilesToFile(data, fleetPercentiles, reports.percentiles()); err != nil {
+        return err
+    }
+
+    // Rank the workloads by the value they return for their cost.
+    efficiencies := laplace.RankEfficiency(data.Workloads, flagRatio)
+    laplace.PrintEfficiencyReport(efficiencies)
+    if err := laplace.WriteEfficiencyToFile(efficiencies, reports.efficiency()); err != nil {
+        return err
+    }
+    if err := laplace.WriteEfficiencyToJSON(efficiencies, reports.efficiencyJSON()); err != nil {
+        return err
+    }
+
+    // Export the aggregated workload data to a CSV file.
+    dimensions := summedDimensions(result.summed)
+    if err := laplace.ExportWorkloadToCSV(result.summed, dimensions, reports.summed()); err != nil {
+        log.Printf("Error exporting data to CSV: %v", err)
+    }
+
+    for _, attribution := range result.peaks {
+        printPeakContributors(attribution)
+    }
+    if err := laplace.WritePeaksToFile(result.peaks, reports.peaks()); err != nil {
+        return err
+    }
+    if result.capacity != nil {
+        laplace.PrintCapacityReport(*result.capacity)
+        if err := laplace.WriteCapacityHeadroomToFile(*result.capacity, reports.capacityHeadroom()); err != nil {
+            return err
+        }
+        if err := laplace.WriteCapacityEventsToFile(*result.capacity, reports.capacityEvents()); err != nil {
+            return err
+        }
+    }
+    if err := laplace.AssignVolatilityTiers(data.Workloads, ranking.metric, ranking.tiers); err != nil {
+        return err
+    }
+    if err := printVolatilityCategories(data.Workloads, ranking); err != nil {
+        return err
+    }
+
+    // Write volatility data to a CSV file.
+    if err := laplace.WriteVolatilityToFile(reports.summed(), reports.volatility(), analysis.interval); err != nil {
+        return err
+    }
+    // Write individual workload volatility data to a CSV file.
+    if err := laplace.WriteWorkloadVolatilityToFile(data, reports.workloadVolatility()); err != nil {
+        return err
+    }
+    // Write the cost of each workload per dimension, tier and time window to a CSV file.
+    return laplace.WriteCostBreakdownToFile(data, reports.costBreakdown())
+}
+
+// summedDimensions returns the sorted dimensions present in the summed series.
+func summedDimensions(summed []laplace.SummedWorkload) []string {
+    seen := make(map[string]bool)
+    var dimensions []string
+    for _, summedWorkload := range summed {
+        for dimension := range summedWorkload.Totals {
+            if !seen[dimension] {
+                seen[dimension] = true
+                dimensions = append(dimensions, dimension)
+            }
+        }
+    }
+    sort.Strings(dimensions)
+    return dimensions
+}
+
+// loadWorkloadFiles loads every workload file in format, or by extension when format is empty, on
+// up to workers goroutines. Every file that fails to decode is logged, and then an error returned.
+func loadWorkloadFiles(files []string, format string, workers int) (*laplace.Data, error) {
+    data, errs := laplace.LoadFiles(files, format, workers)
+    failed := 0
+    for i, err := range errs {
+        if err != nil {
+            log.Printf("Error loading data from file %s: %v", files[i], err)
+            failed++
+        }
+    }
+    if failed > 0 {
+        return nil, fmt.Errorf("%d of %d files failed to load", failed, len(files))
+    }
+    return data, nil
+}
+
+// printPeakContributors prints a peak and the top 10% of workloads contributing to it.
+func printPeakContributors(attribution laplace.PeakAttribution) {
+    // Display the peak usage information.
+    peakUsage := attribution.Peak
+    fmt.Printf("\nPeak Usage Information (%s, peak %d):\n", peakUsage.Dimension, attribution.Rank)
+    fmt.Printf("Timestamp of Peak Usage: %v\n", peakUsage.Timestamp)
+    fmt.Printf("Total Usage at Peak: %.2f\n", peakUsage.TotalUsage)
+
+    // Identify the top 10% contributors at the peak usage; the contributors are sorted highest first.
+    contributions := attribution.Contributors
+    topTenPercentIndex := len(contributions) / 10
+    topContributors := contributions[:topTenPercentIndex]
+
+    // Display the top contributing workloads.
+    fmt.Println("\nTop 10% Workloads Contributing to Peak Usage:")
+    for _, contributor := range topContributors {
+        fmt.Printf("Workload: %s, Load at Peak: %.2f\n", contributor.Name, contributor.LoadAtPeak)
+    }
+}
+
+// printVolatilityCategories prints the workloads in each volatility tier assigned by
+// AssignVolatilityTiers, highest tier first, ranked by the volatility metric they were tiered by.
+func printVolatilityCategories(workloads []laplace.Workload, ranking volatilityRanking) error {
+    volatilities, err := laplace.RankVolatility(workloads, ranking.metric)
+    if err != nil {
+        return err
+    }
+    tiers := make(map[string]string, len(workloads))
+    for _, workload := range workloads {
+        tiers[workload.Name] = workload.VolatilityTier
+    }
+
+    // Display the workloads in each volatility tier.
+    labels := ranking.tiers.TierLabels()
+    for i := len(labels) - 1; i >= 0; i-- {
+        fmt.Printf("\n%s Volatility Workloads:\n", labels[i])
+        for _, workload := range volatilities {
+            if tiers[workload.Name] == labels[i] {
+                fmt.Println(workload.Name)
+            }
+        }
+    }
+    return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+
+    "github.com/codyshoward/laplace"
+)
+
+// runAnomalies detects anomalies in the load series of each workload named by --in, and of the
+// fleet's summed load, and writes them to anomalies.csv.
+func runAnomalies(args []string) error {
+    defaults := laplace.DefaultAnomalyOptions()
+    flags := flag.NewFlagSet("anomalies", flag.ContinueOnError)
+    opts := addWorkloadFlags(flags)
+    method := flags.String("method", defaults.Method, "detection method: zscore, mad or seasonal")
+    window := flags.Duration("window", defaults.Window, "span of preceding values the zscore and mad methods compare each value against")
+    season := flags.Duration("season", defaults.Season, "period the seasonal method compares each value across")
+    threshold := flags.Float64("threshold", defaults.Threshold, "score above which a value is anomalous")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+
+    anomalyOptions := laplace.AnomalyOptions{Method: *method, Window: *window, Season: *season, Threshold: *threshold}
+
+    reports, err := opts.reports()
+    if err != nil {
+        return err
+    }
+    data, err := opts.load()
+    if err != nil {
+        return err
+    }
+
+    anomalies, err := laplace.DetectAnomalies(data.Workloads, anomalyOptions, opts.workers)
+    if err != nil {
+        return err
+    }
+    summed, err := laplace.AggregateWorkloads(data.Workloads, opts.workers)
+    if err != nil {
+        return fmt.Errorf("aggregating workloads: %w", err)
+    }
+    fleet, err := laplace.DetectSummedAnomalies(summed, anomalyOptions)
+    if err != nil {
+        return err
+    }
+
+    fmt.Printf("Workload anomalies: %d\n", len(anomalies))
+    for _, anomaly := range fleet {
+        fmt.Printf("Fleet %s %s anomaly %v to %v: %.2f at %v, expected %.2f, severity %.2f\n",
+            anomaly.Dimension, anomaly.Kind, anomaly.Start, anomaly.End, anomaly.Value, anomaly.Timestamp, anomaly.Expected, anomaly.Severity)
+    }
+
+    return laplace.WriteAnomaliesToFile(append(fleet, anomalies...), reports.anomalies())
+}
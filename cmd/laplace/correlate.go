@@ -0,0 +1,40 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+
+    "github.com/codyshoward/laplace"
+)
+
+// runCorrelate writes the Pearson and Spearman correlation matrices of the workloads named by --in
+// for every load dimension and the total load, and prints the most (anti-)correlated pairs.
+func runCorrelate(args []string) error {
+    flags := flag.NewFlagSet("correlate", flag.ContinueOnError)
+    opts := addWorkloadFlags(flags)
+    top := flags.Int("top", 5, "number of most correlated and anti-correlated pairs to print per matrix")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if *top < 0 {
+        return fmt.Errorf("--top must not be negative")
+    }
+
+    reports, err := opts.reports()
+    if err != nil {
+        return err
+    }
+    data, err := opts.load()
+    if err != nil {
+        return err
+    }
+
+    matrices := laplace.CalculateCorrelations(data.Workloads, opts.workers)
+    laplace.PrintCorrelationSummary(matrices, *top)
+    for _, matrix := range matrices {
+        if err := laplace.WriteCorrelationMatrixToFile(matrix, reports.correlation(matrix.Method, matrix.Dimension)); err != nil {
+            return err
+        }
+    }
+    return nil
+}
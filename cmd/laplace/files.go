@@ -0,0 +1,174 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+
+    "github.com/codyshoward/laplace"
+)
+
+// defaultWorkloadPattern matches the workload files written by generate.
+const defaultWorkloadPattern = "Workload*.json"
+
+// defaultWorkloadPatterns returns the patterns matched inside --in directories when --pattern isn't
+// given: the files written by generate, or with --format every file of that format's extensions.
+func defaultWorkloadPatterns(format string) []string {
+    switch format {
+    case laplace.FormatCSV:
+        return []string{"*.csv"}
+    case laplace.FormatJSONL:
+        return []string{"*.jsonl", "*.ndjson"}
+    case laplace.FormatPrometheus:
+        return []string{"*.prom"}
+    case laplace.FormatRemoteRead:
+        return []string{"*.pb"}
+    }
+    return []string{defaultWorkloadPattern}
+}
+
+// stringList is a flag.Value collecting every occurrence of a repeatable flag.
+type stringList []string
+
+func (l *stringList) String() string {
+    return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+    *l = append(*l, value)
+    return nil
+}
+
+// resolveInputs expands each input into workload files. A directory contributes the files in it
+// matching any of patterns, anything else is treated as a file path or glob. The result is sorted
+// and free of duplicates so every run reads the files in the same order.
+func resolveInputs(inputs []string, patterns []string) ([]string, error) {
+    for _, pattern := range patterns {
+        if _, err := filepath.Match(pattern, ""); err != nil {
+            return nil, fmt.Errorf("bad pattern %q: %w", pattern, err)
+        }
+    }
+
+    seen := make(map[string]bool)
+    var files []string
+    for _, input := range inputs {
+        var matches []string
+        info, err := os.Stat(input)
+        switch {
+        case err == nil && info.IsDir():
+            for _, pattern := range patterns {
+                patternMatches, _ := filepath.Glob(filepath.Join(input, pattern)) // patterns are checked above
+                matches = append(matches, patternMatches...)
+            }
+        case err == nil:
+            matches = []string{input}
+        default:
+            matches, err = filepath.Glob(input)
+            if err == nil && len(matches) == 0 {
+                err = fmt.Errorf("no files match %s", input)
+            }
+        }
+        if err != nil {
+            return nil, err
+        }
+
+        for _, match := range matches {
+            if !seen[match] {
+                seen[match] = true
+                files = append(files, match)
+            }
+        }
+    }
+    sort.Strings(files)
+    return files, nil
+}
+
+// reportFiles names the CSV reports written by analyze and read back by plot.
+// Every file lives in dir and starts with prefix, so runs for different clusters can share a
+// directory without overwriting each other.
+type reportFiles struct {
+    dir    string
+    prefix string
+}
+
+func (r reportFiles) path(name string) string {
+    return filepath.Join(r.dir, r.prefix+name)
+}
+
+// summed is the per-timestamp total of every load dimension.
+func (r reportFiles) summed() string { return r.path("output.csv") }
+
+// volatility is the volatility of the summed loads over time.
+func (r reportFiles) volatility() string { return r.path("volatility_output.csv") }
+
+// workloadVolatility is the volatility of each workload.
+func (r reportFiles) workloadVolatility() string { return r.path("workload_volatility.csv") }
+
+// intervalChanges is the change in each workload's load from one interval to the next.
+func (r reportFiles) intervalChanges() string { return r.path("workload_volatility_intervals.csv") }
+
+// efficiency is the cost-versus-value ranking of the workloads, with efficiencyJSON its JSON form.
+func (r reportFiles) efficiency() string { return r.path("efficiency.csv") }
+
+func (r reportFiles) efficiencyJSON() string { return r.path("efficiency.json") }
+
+// placement is the host each workload was assigned to by place.
+func (r reportFiles) placement() string { return r.path("placement.csv") }
+
+// correlation is the matrix of correlations between workloads in one dimension, e.g.
+// correlation_pearson_total.csv.
+func (r reportFiles) correlation(method, dimension string) string {
+    return r.path(fmt.Sprintf("correlation_%s_%s.csv", method, fileNamePart(dimension)))
+}
+
+// peaks is the workloads contributing to each of the top peaks, overall and per dimension.
+func (r reportFiles) peaks() string { return r.path("peaks.csv") }
+
+// percentiles is the p50, p95 and p99 of each workload's dimensions and of the fleet's summed load.
+func (r reportFiles) percentiles() string { return r.path("percentiles.csv") }
+
+// capacityHeadroom is the headroom left under each capacity limit, and capacityEvents every
+// warning and breach of the limits.
+func (r reportFiles) capacityHeadroom() string { return r.path("capacity_headroom.csv") }
+
+func (r reportFiles) capacityEvents() string { return r.path("capacity_events.csv") }
+
+// forecast is the fleet's forecast summed load in the layout of summed, with forecastLower and
+// forecastUpper the edges of its confidence band.
+func (r reportFiles) forecast() string { return r.path("forecast.csv") }
+
+func (r reportFiles) forecastLower() string { return r.path("forecast_lower.csv") }
+
+func (r reportFiles) forecastUpper() string { return r.path("forecast_upper.csv") }
+
+// workloadForecasts is the forecast of each workload's load dimensions.
+func (r reportFiles) workloadForecasts() string { return r.path("workload_forecasts.csv") }
+
+// anomalies is every anomaly found in the fleet's summed load and in each workload's load.
+func (r reportFiles) anomalies() string { return r.path("anomalies.csv") }
+
+// gaps is the number of values synthesized in each workload's dimensions by --fill.
+func (r reportFiles) gaps() string { return r.path("gaps.csv") }
+
+// validation is the JSON report of the problems found in the workload files by validate.
+func (r reportFiles) validation() string { return r.path("validation.json") }
+
+// imported holds the workloads import mapped from Prometheus series.
+func (r reportFiles) imported() string { return r.path("prometheus.json") }
+
+// costBreakdown is the cost of each workload per dimension, volume tier and time window.
+func (r reportFiles) costBreakdown() string { return r.path("cost_breakdown.csv") }
+
+// fileNamePart replaces the characters of name that aren't letters, digits, '-', '_' or '.' with
+// '_', so a dimension such as "disk/read" can be part of a file name.
+func fileNamePart(name string) string {
+    return strings.Map(func(r rune) rune {
+        switch {
+        case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+            return r
+        }
+        return '_'
+    }, name)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+
+    "github.com/codyshoward/laplace"
+)
+
+// runForecast projects the load of each workload named by --in, and of the fleet, forward by
+// --horizon and writes the forecasts with their confidence bands.
+func runForecast(args []string) error {
+    defaults := laplace.DefaultForecastOptions()
+    flags := flag.NewFlagSet("forecast", flag.ContinueOnError)
+    opts := addWorkloadFlags(flags)
+    method := flags.String("method", defaults.Method, "forecasting method: holt-winters or seasonal-naive")
+    horizon := flags.Duration("horizon", defaults.Horizon, "how far past the last timestamp to forecast, e.g. 6h or 168h")
+    season := flags.Duration("season", defaults.Season, "length of the repeating pattern in the load")
+    confidence := flags.Float64("confidence", defaults.Confidence, "coverage of the confidence band, between 0 and 1")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+
+    forecastOptions := defaults
+    forecastOptions.Method = *method
+    forecastOptions.Horizon = *horizon
+    forecastOptions.Season = *season
+    forecastOptions.Confidence = *confidence
+
+    reports, err := opts.reports()
+    if err != nil {
+        return err
+    }
+    data, err := opts.load()
+    if err != nil {
+        return err
+    }
+
+    // Forecast the fleet's summed load and write it in the layout of output.csv so it can be
+    // overlaid on the actuals by "laplace plot --kind forecast".
+    summed, err := laplace.AggregateWorkloads(data.Workloads, opts.workers)
+    if err != nil {
+        return fmt.Errorf("aggregating workloads: %w", err)
+    }
+    fleet, err := laplace.ForecastSummed(summed, forecastOptions)
+    if err != nil {
+        return err
+    }
+    values, lower, upper := laplace.ForecastsToSummed(fleet)
+    dimensions := summedDimensions(summed)
+    if err := laplace.ExportWorkloadToCSV(values, dimensions, reports.forecast()); err != nil {
+        return err
+    }
+    if err := laplace.ExportWorkloadToCSV(lower, dimensions, reports.forecastLower()); err != nil {
+        return err
+    }
+    if err := laplace.ExportWorkloadToCSV(upper, dimensions, reports.forecastUpper()); err != nil {
+        return err
+    }
+    for _, dimension := range dimensions {
+        forecast := fleet[dimension]
+        if len(forecast.Points) == 0 {
+            continue
+        }
+        last := forecast.Points[len(forecast.Points)-1]
+        fmt.Printf("Fleet %s forecast (%s) at %v: %.2f [%.2f, %.2f]\n", dimension, forecast.Method, last.Timestamp, last.Value, last.Lower, last.Upper)
+    }
+
+    // Forecast every workload.
+    workloadForecasts, err := laplace.ForecastWorkloads(data.Workloads, forecastOptions, opts.workers)
+    if err != nil {
+        return err
+    }
+    return laplace.WriteWorkloadForecastsToFile(workloadForecasts, reports.workloadForecasts())
+}
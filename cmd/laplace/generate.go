@@ -0,0 +1,58 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "math/rand"
+    "os"
+    "path/filepath"
+    "time"
+
+    "github.com/codyshoward/laplace"
+)
+
+// runGenerate writes randomly generated workloads to DIR/<name>.json.
+func runGenerate(args []string) error {
+    flags := flag.NewFlagSet("generate", flag.ContinueOnError)
+    numWorkloads := flags.Int("workloads", 10, "number of workloads to generate")
+    numIntegers := flags.Int("points", 60, "number of values in each load")
+    dimensionList := flags.String("dimensions", "load1,load2,load3", "comma-separated load dimensions")
+    outDir := flags.String("out", ".", "directory to write the workload files to")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+
+    if *numWorkloads < 0 || *numIntegers < 0 {
+        return fmt.Errorf("--workloads and --points must not be negative")
+    }
+    if err := os.MkdirAll(*outDir, 0o755); err != nil {
+        return err
+    }
+
+    rand.Seed(time.Now().UnixNano())
+
+    // Generate workloads with a mix of volatilities
+    dimensions := laplace.ParseDimensions(*dimensionList)
+    for _, dimension := range dimensions {
+        if err := laplace.CheckDimension(dimension); err != nil {
+            return fmt.Errorf("--dimensions: %w", err)
+        }
+    }
+    workloads := laplace.GenerateWorkloads(*numWorkloads, *numIntegers, dimensions)
+
+    for _, workload := range workloads {
+        data, err := workload.SerializeToJson()
+        if err != nil {
+            return fmt.Errorf("marshaling %s: %w", workload.Name, err)
+        }
+
+        // Writing JSON data to a file
+        path := filepath.Join(*outDir, fmt.Sprintf("%s.json", workload.Name))
+        if err := os.WriteFile(path, data, 0o644); err != nil {
+            return err
+        }
+
+        fmt.Printf("Workload %s generated and saved in %s\n", workload.Name, path)
+    }
+    return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+    "encoding/json"
+    "flag"
+    "fmt"
+    "os"
+    "strings"
+    "time"
+
+    "github.com/codyshoward/laplace"
+)
+
+// runImport reads Prometheus series from a text exposition or remote-read response, in a file or
+// served by an endpoint, maps them onto workloads, writes those to prometheus.json and prints their
+// statistics. prometheus.json doesn't match the default --pattern, so the other commands read it
+// when named by --in or picked by --pattern.
+func runImport(args []string) error {
+    flags := flag.NewFlagSet("import", flag.ContinueOnError)
+    analysis := addAnalysisFlags(flags)
+    from := flags.String("from", "", "Prometheus file or http(s) URL to import, e.g. http://localhost:9090/api/v1/read (required)")
+    format := flags.String("format", "", "prom (text exposition) or remote-read (default remote-read for .pb files and URLs ending in /read, otherwise prom)")
+    var metrics, matchers stringList
+    flags.Var(&metrics, "metric", "metric to import as DIMENSION=METRIC, or METRIC to keep its name; repeatable (default every metric)")
+    flags.Var(&matchers, "match", "only import series whose label NAME equals VALUE, as NAME=VALUE; repeatable")
+    workloadLabels := flags.String("workload-label", "instance", "comma-separated labels whose values name each series' workload")
+    since := flags.Duration("since", time.Hour, "how far before --until a remote-read query reaches")
+    until := flags.String("until", "", "end of a remote-read query, RFC 3339 (default now)")
+    outDir := flags.String("out", ".", "directory to write prometheus.json to")
+    prefix := flags.String("prefix", "", "prefix for the file name, e.g. \"cluster-a_\"")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if err := analysis.validate(); err != nil {
+        return err
+    }
+    if *from == "" {
+        return fmt.Errorf("--from is required")
+    }
+    if *since <= 0 {
+        return fmt.Errorf("--since must be positive, got %v", *since)
+    }
+
+    opts := laplace.PrometheusOptions{
+        Metrics:        make(map[string]string),
+        WorkloadLabels: parseList(*workloadLabels),
+        Matchers:       make(map[string]string),
+        End:            time.Now(),
+    }
+    if len(opts.WorkloadLabels) == 0 {
+        return fmt.Errorf("--workload-label must name at least one label")
+    }
+    for _, metric := range metrics {
+        dimension, name, found := strings.Cut(metric, "=")
+        if !found {
+            name = dimension
+        }
+        if dimension == "" || name == "" {
+            return fmt.Errorf("bad --metric %q: want DIMENSION=METRIC or METRIC", metric)
+        }
+        opts.Metrics[name] = dimension
+    }
+    for _, matcher := range matchers {
+        name, value, found := strings.Cut(matcher, "=")
+        if !found || name == "" {
+            return fmt.Errorf("bad --match %q: want NAME=VALUE", matcher)
+        }
+        opts.Matchers[name] = value
+    }
+    if *until != "" {
+        end, err := time.Parse(time.RFC3339, *until)
+        if err != nil {
+            return fmt.Errorf("bad --until: %w", err)
+        }
+        opts.End = end
+    }
+    opts.Start = opts.End.Add(-*since)
+
+    pricing, err := analysis.pricing()
+    if err != nil {
+        return err
+    }
+    if err := os.MkdirAll(*outDir, 0o755); err != nil {
+        return err
+    }
+    reports := reportFiles{dir: *outDir, prefix: *prefix}
+
+    data, err := laplace.ImportPrometheus(*from, *format, opts)
+    if err != nil {
+        return err
+    }
+    if len(data.Workloads) == 0 {
+        return fmt.Errorf("no series in %s match the selected metrics and labels", *from)
+    }
+
+    contents, err := json.MarshalIndent(data, "", "    ")
+    if err != nil {
+        return err
+    }
+    if err := os.WriteFile(reports.imported(), contents, 0o644); err != nil {
+        return err
+    }
+    fmt.Printf("Imported %d workloads from %s and saved them in %s\n\n", len(data.Workloads), *from, reports.imported())
+
+    laplace.CalculateWorkloadStats(data, analysis.interval, pricing, 0)
+    laplace.PrintWorkloadStats(*data)
+    return nil
+}
@@ -0,0 +1,80 @@
+// Command laplace generates, analyzes and plots workloads.
+//
+// Usage:
+//
+//    laplace generate --workloads N --points M --out DIR
+//    laplace analyze --in DIR --out DIR [--stream]
+//    laplace plot --kind all|individual|vol_interval|changes|heatmap|forecast
+//    laplace correlate --in DIR --out DIR
+//    laplace place --in DIR --hosts hosts.json --out DIR
+//    laplace forecast --in DIR --out DIR --horizon 24h
+//    laplace anomalies --in DIR --out DIR --method zscore|mad|seasonal
+//    laplace validate --in DIR --out DIR
+//    laplace import --from FILE|URL --out DIR [--metric DIMENSION=METRIC]
+package main
+
+import (
+    "errors"
+    "flag"
+    "fmt"
+    "os"
+)
+
+// command is a laplace subcommand. run receives the arguments following the subcommand name.
+type command struct {
+    name    string
+    summary string
+    run     func(args []string) error
+}
+
+var commands = []command{
+    {name: "generate", summary: "generate random Workload*.json files", run: runGenerate},
+    {name: "analyze", summary: "analyze Workload*.json files and write the CSV reports", run: runAnalyze},
+    {name: "plot", summary: "plot the CSV reports written by analyze", run: runPlot},
+    {name: "correlate", summary: "write correlation matrices between workloads' load series", run: runCorrelate},
+    {name: "place", summary: "assign workloads to hosts balancing peak utilization, cost and volatility", run: runPlace},
+    {name: "forecast", summary: "forecast workloads' and the fleet's load with confidence bands", run: runForecast},
+    {name: "anomalies", summary: "detect anomalies in workloads' and the fleet's load series", run: runAnomalies},
+    {name: "validate", summary: "check workload files for malformed series and write a JSON report", run: runValidate},
+    {name: "import", summary: "import workloads from a Prometheus exposition dump or remote-read endpoint", run: runImport},
+}
+
+func main() {
+    if len(os.Args) < 2 {
+        printUsage()
+        os.Exit(2)
+    }
+
+    name := os.Args[1]
+    for _, cmd := range commands {
+        if cmd.name != name {
+            continue
+        }
+        err := cmd.run(os.Args[2:])
+        if errors.Is(err, flag.ErrHelp) {
+            return // The flag set has already printed its usage.
+        }
+        if err != nil {
+            fmt.Fprintf(os.Stderr, "laplace %s: %v\n", name, err)
+            os.Exit(1)
+        }
+        return
+    }
+
+    if name != "help" && name != "-h" && name != "--help" {
+        fmt.Fprintf(os.Stderr, "laplace: unknown command %q\n\n", name)
+    }
+    printUsage()
+    os.Exit(2)
+}
+
+func printUsage() {
+    fmt.Fprintln(os.Stderr, "Usage: laplace <command> [flags]")
+    fmt.Fprintln(os.Stderr)
+    fmt.Fprintln(os.Stderr, "Commands:")
+    for _, cmd := range commands {
+        fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.summary)
+    }
+    fmt.Fprintln(os.Stderr)
+    fmt.Fprintln(os.Stderr, "Run 'laplace <command> -h' for the flags of a command.")
+}
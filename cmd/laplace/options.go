@@ -0,0 +1,134 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "os"
+    "time"
+
+    "github.com/codyshoward/laplace"
+)
+
+// workloadOptions are the flags shared by the subcommands that read workload files and write reports.
+type workloadOptions struct {
+    inputs  stringList
+    pattern string
+    format  string
+    outDir  string
+    prefix  string
+    workers int
+    gaps    laplace.GapOptions
+    step    time.Duration
+    combine string
+}
+
+// addWorkloadFlags registers the shared workload input and report flags on flags.
+func addWorkloadFlags(flags *flag.FlagSet) *workloadOptions {
+    opts := &workloadOptions{}
+    flags.Var(&opts.inputs, "in", "directory, file or glob of workload files; repeatable (default \".\")")
+    flags.StringVar(&opts.pattern, "pattern", "", "file name pattern matched inside --in directories (default Workload*.json, or with --format *.csv, *.jsonl and *.ndjson, *.prom or *.pb)")
+    flags.StringVar(&opts.format, "format", "", "input format of the workload files: json, csv, jsonl, prom or remote-read (default by extension: .csv, .jsonl or .ndjson, .prom, .pb, otherwise json)")
+    flags.StringVar(&opts.outDir, "out", ".", "directory to write the reports to")
+    flags.StringVar(&opts.prefix, "prefix", "", "prefix for the report file names, e.g. \"cluster-a_\"")
+    flags.IntVar(&opts.workers, "workers", 0, "number of workloads processed in parallel (default one per CPU)")
+    flags.StringVar(&opts.gaps.Strategy, "fill", laplace.GapMissing, "how values missing from a dimension are filled: missing, zero, ffill, linear or mean")
+    flags.DurationVar(&opts.gaps.Step, "fill-step", 0, "also fill every step between a workload's first and last timestamp, e.g. 1m (default --resample)")
+    flags.DurationVar(&opts.step, "resample", 0, "convert every load series to one value per step before analysis, e.g. 1m or 5m")
+    flags.StringVar(&opts.combine, "resample-agg", laplace.ResampleMean, "how --resample combines the values in a step: sum, mean, max or last, optionally per dimension, e.g. mean,net_egress=sum")
+    return opts
+}
+
+// reports creates the output directory and returns the report file names within it.
+func (o *workloadOptions) reports() (reportFiles, error) {
+    if err := os.MkdirAll(o.outDir, 0o755); err != nil {
+        return reportFiles{}, err
+    }
+    return reportFiles{dir: o.outDir, prefix: o.prefix}, nil
+}
+
+// files returns every workload file named by --in, defaulting to the current directory, after
+// checking --format.
+func (o *workloadOptions) files() ([]string, error) {
+    if o.format != "" {
+        if _, err := laplace.NewWorkloadReader(o.format); err != nil {
+            return nil, err
+        }
+    }
+    inputs := o.inputs
+    if len(inputs) == 0 {
+        inputs = stringList{"."}
+    }
+    patterns := defaultWorkloadPatterns(o.format)
+    if o.pattern != "" {
+        patterns = []string{o.pattern}
+    }
+    return resolveInputs(inputs, patterns)
+}
+
+// load reads every workload file named by --in, resamples their loads to --resample and fills
+// the gaps in them as --fill says.
+func (o *workloadOptions) load() (*laplace.Data, error) {
+    gaps := o.gaps
+    if gaps.Step == 0 {
+        gaps.Step = o.step
+    }
+    if err := gaps.Validate(); err != nil {
+        return nil, err
+    }
+    var resample *laplace.ResampleOptions
+    if o.step != 0 {
+        aggregation, dimensions := laplace.ParseResampleAggregation(o.combine)
+        resample = &laplace.ResampleOptions{Step: o.step, Aggregation: aggregation, Dimensions: dimensions}
+        if err := resample.Validate(); err != nil {
+            return nil, err
+        }
+    }
+
+    files, err := o.files()
+    if err != nil {
+        return nil, err
+    }
+    data, err := loadWorkloadFiles(files, o.format, o.workers)
+    if err != nil {
+        return nil, err
+    }
+    if resample != nil {
+        if err := laplace.Resample(data.Workloads, *resample, o.workers); err != nil {
+            return nil, err
+        }
+    }
+    if err := laplace.FillGaps(data.Workloads, gaps, o.workers); err != nil {
+        return nil, err
+    }
+    return data, nil
+}
+
+// analysisOptions are the flags shared by the subcommands that calculate workload statistics.
+type analysisOptions struct {
+    interval    time.Duration
+    pricingFile string
+}
+
+// addAnalysisFlags registers the shared statistics flags on flags.
+func addAnalysisFlags(flags *flag.FlagSet) *analysisOptions {
+    opts := &analysisOptions{}
+    flags.DurationVar(&opts.interval, "interval", laplace.DefaultVolatilityInterval, "window length for volatility, e.g. 1m, 15m or 1h")
+    flags.StringVar(&opts.pricingFile, "pricing", "", "JSON file of unit prices per load dimension (default: 1 per unit of load)")
+    return opts
+}
+
+// validate checks the parsed flags.
+func (o *analysisOptions) validate() error {
+    if o.interval <= 0 {
+        return fmt.Errorf("--interval must be positive, got %v", o.interval)
+    }
+    return nil
+}
+
+// pricing loads the --pricing file, or returns flat pricing when none is given.
+func (o *analysisOptions) pricing() (laplace.Pricing, error) {
+    if o.pricingFile == "" {
+        return laplace.FlatPricing(), nil
+    }
+    return laplace.LoadPricing(o.pricingFile)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+
+    "github.com/codyshoward/laplace"
+)
+
+// runPlace assigns the workloads named by --in to the hosts in --hosts, balancing peak
+// utilization against cost and volatility, and writes the assignment to placement.csv.
+func runPlace(args []string) error {
+    flags := flag.NewFlagSet("place", flag.ContinueOnError)
+    opts := addWorkloadFlags(flags)
+    analysis := addAnalysisFlags(flags)
+    hostsFile := flags.String("hosts", "", "JSON file of hosts and their capacity per load dimension (required)")
+    costWeight := flags.Float64("cost-weight", 0.5, "weight of spreading cost evenly across hosts")
+    volatilityWeight := flags.Float64("volatility-weight", 0.5, "weight of keeping each host's summed load steady")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if err := analysis.validate(); err != nil {
+        return err
+    }
+    if *hostsFile == "" {
+        return fmt.Errorf("--hosts is required")
+    }
+    if *costWeight < 0 || *volatilityWeight < 0 {
+        return fmt.Errorf("--cost-weight and --volatility-weight must not be negative")
+    }
+
+    hosts, err := laplace.LoadHosts(*hostsFile)
+    if err != nil {
+        return err
+    }
+    reports, err := opts.reports()
+    if err != nil {
+        return err
+    }
+    pricing, err := analysis.pricing()
+    if err != nil {
+        return err
+    }
+    data, err := opts.load()
+    if err != nil {
+        return err
+    }
+
+    // Workload costs feed the cost balancing.
+    laplace.CalculateWorkloadStats(data, analysis.interval, pricing, opts.workers)
+
+    weights := laplace.PlacementWeights{Cost: *costWeight, Volatility: *volatilityWeight}
+    placement, err := laplace.PlaceWorkloads(data.Workloads, hosts, weights, analysis.interval)
+    if err != nil {
+        return err
+    }
+
+    laplace.PrintPlacement(placement)
+    return laplace.WritePlacementToFile(placement, reports.placement())
+}
@@ -0,0 +1,569 @@
+package main
+
+import (
+    "encoding/csv"
+    "flag"
+    "fmt"
+    "os"
+    "strconv"
+    "image/color"
+    "time"
+    "gonum.org/v1/plot"
+    "gonum.org/v1/plot/plotter"
+    "gonum.org/v1/plot/plotutil"
+    "gonum.org/v1/plot/vg"
+    "gonum.org/v1/plot/vg/draw"
+    "gonum.org/v1/plot/palette/moreland"
+    "math/rand"
+
+    "github.com/codyshoward/laplace"
+)
+// runPlot renders one kind of plot from the CSV reports written by analyze.
+func runPlot(args []string) error {
+    flags := flag.NewFlagSet("plot", flag.ContinueOnError)
+    kind := flags.String("kind", "all", "plot type: 'all' for all workloads, 'individual' for individual workloads, 'vol_interval' for volatility intervals, 'changes' for workload changes, 'heatmap' for a correlation matrix, 'forecast' for the fleet forecast over the actuals")
+    method := flags.String("method", laplace.Pearson, "correlation method of the heatmap: pearson or spearman")
+    dimension := flags.String("dimension", laplace.TotalDimension, "load dimension of the heatmap")
+    inDir := flags.String("in", ".", "directory containing the CSV reports written by analyze")
+    prefix := flags.String("prefix", "", "file name prefix the reports were written with by analyze")
+    outDir := flags.String("out", ".", "directory to write the plot to")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+
+    if err := os.MkdirAll(*outDir, 0o755); err != nil {
+        return err
+    }
+    rand.Seed(time.Now().UnixNano())
+
+    reports := reportFiles{dir: *inDir, prefix: *prefix}
+    plots := reportFiles{dir: *outDir, prefix: *prefix}
+
+    switch *kind {
+    case "all":
+        return plotAllWorkloads(reports.summed(), plots.path("all_workloads_plot.pdf"))
+    case "individual":
+        return plotWorkload(reports.summed(), reports.anomalies(), plots.path("workload_plot.pdf"))
+    case "vol_interval":
+        return plotWorkloadVolatilityIntervals(reports.volatility(), plots.path("volatility_intervals_plot.pdf"))
+    case "changes":
+        return plotWorkloadChanges(reports.intervalChanges(), plots.path("workload_changes_plot.png"))
+    case "heatmap":
+        output := plots.path(fmt.Sprintf("correlation_%s_%s_heatmap.png", *method, *dimension))
+        return plotCorrelationHeatmap(reports.correlation(*method, *dimension), output)
+    case "forecast":
+        bands := [3]string{reports.forecast(), reports.forecastLower(), reports.forecastUpper()}
+        return plotForecast(reports.summed(), bands, plots.path("forecast_plot.pdf"))
+    default:
+        return fmt.Errorf("unknown --kind %q: want all, individual, vol_interval, changes, heatmap or forecast", *kind)
+    }
+}
+
+// plotWorkload plots the summed load of each dimension. When anomalyFile exists, the most severe
+// value of each fleet anomaly found by the anomalies command is marked on its dimension's line.
+func plotWorkload(csvFile, anomalyFile, pdfFile string) error {
+    f, err := os.Open(csvFile)
+    if err != nil {
+        return err
+    }
+    defer f.Close()
+
+    r := csv.NewReader(f)
+    records, err := r.ReadAll()
+    if err != nil {
+        return err
+    }
+
+    p := plot.New()
+    p.Title.Text = "Workload Over Time"
+    p.X.Label.Text = "Time"
+    p.Y.Label.Text = "Workload"
+
+    for i := 1; i < len(records[0]); i++ {
+        var pts plotter.XYs
+        for j, record := range records {
+            if j == 0 {
+                continue
+            }
+            t, err := time.Parse(time.RFC3339, record[0])
+            if err != nil {
+                return err
+            }
+            x := float64(t.Unix())
+            y, err := strconv.ParseFloat(record[i], 64)
+            if err != nil {
+                return err
+            }
+            pts = append(pts, plotter.XY{X: x, Y: y})
+        }
+        line, points, err := plotter.NewLinePoints(pts)
+        if err != nil {
+            return err
+        }
+        line.Color = plotutil.Color(i)
+        points.Shape = draw.CircleGlyph{} // Use draw.CircleGlyph
+        p.Add(line, points)
+        p.Legend.Add(records[0][i], line, points) // Header names the load dimension
+    }
+
+    anomalies, err := readFleetAnomalies(anomalyFile)
+    if err != nil {
+        return err
+    }
+    for i := 1; i < len(records[0]); i++ {
+        marks := anomalies[records[0][i]]
+        if len(marks) == 0 {
+            continue
+        }
+        scatter, err := plotter.NewScatter(marks)
+        if err != nil {
+            return err
+        }
+        scatter.Shape = draw.CrossGlyph{}
+        scatter.Color = color.RGBA{R: 255, A: 255}
+        scatter.Radius = vg.Points(6)
+        p.Add(scatter)
+        p.Legend.Add(records[0][i]+" anomaly", scatter)
+    }
+
+    p.X.Tick.Marker = plot.TimeTicks{Format: "15:04"}
+    p.X.Tick.Length = vg.Points(10)
+    p.Legend.Top = false
+    p.Legend.Left = false
+
+    if err := p.Save(18*vg.Inch, 6*vg.Inch, pdfFile); err != nil {
+        return err
+    }
+    return nil
+}
+
+// readFleetAnomalies returns the most severe point of each fleet anomaly in the CSV file written
+// by the anomalies command, by dimension. A missing file has no anomalies.
+func readFleetAnomalies(csvFile string) (map[string]plotter.XYs, error) {
+    f, err := os.Open(csvFile)
+    if os.IsNotExist(err) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+
+    records, err := csv.NewReader(f).ReadAll()
+    if err != nil {
+        return nil, err
+    }
+
+    anomalies := make(map[string]plotter.XYs)
+    for i, record := range records {
+        // Columns: Scope, Workload, Dimension, Kind, Start, End, Points, Timestamp, Value, ...
+        if i == 0 || record[0] != laplace.FleetScope {
+            continue
+        }
+        t, err := time.Parse(time.RFC3339, record[7])
+        if err != nil {
+            return nil, err
+        }
+        y, err := strconv.ParseFloat(record[8], 64)
+        if err != nil {
+            return nil, err
+        }
+        anomalies[record[2]] = append(anomalies[record[2]], plotter.XY{X: float64(t.Unix()), Y: y})
+    }
+    return anomalies, nil
+}
+
+func plotAllWorkloads(csvFile, pdfFile string) error {
+    f, err := os.Open(csvFile)
+    if err != nil {
+        return err
+    }
+    defer f.Close()
+
+    r := csv.NewReader(f)
+    records, err := r.ReadAll()
+    if err != nil {
+        return err
+    }
+
+    p := plot.New()
+    p.Title.Text = "Workload Over Time"
+    p.X.Label.Text = "Time"
+    p.Y.Label.Text = "Workload"
+
+    for i := 1; i < len(records[0]); i++ {
+        var pts plotter.XYs
+        for j, record := range records {
+            if j == 0 {
+                continue  // Skip the header row
+            }
+            t, err := time.Parse(time.RFC3339, record[0])
+            if err != nil {
+                return err
+            }
+            x := float64(t.Unix())
+            y, err := strconv.ParseFloat(record[i], 64)
+            if err != nil {
+                return err
+            }
+            pts = append(pts, plotter.XY{X: x, Y: y})
+        }
+        line, points, err := plotter.NewLinePoints(pts)
+        if err != nil {
+            return err
+        }
+        line.Color = plotutil.Color(i - 1) // Dynamically assign color
+        points.Shape = draw.CircleGlyph{}  // Use draw.CircleGlyph
+        p.Add(line, points)
+        p.Legend.Add(records[0][i], line, points) // Header names the load dimension
+    }
+
+    p.X.Tick.Marker = plot.TimeTicks{Format: "15:04"}
+    p.X.Tick.Length = vg.Points(10)
+    p.Legend.Top = false
+
+if err := p.Save(16*vg.Inch, 4*vg.Inch, pdfFile); err != nil {  // Increased width to 8 inches
+    return err
+}
+    return nil
+}
+
+func plotWorkloadVolatilityIntervals(csvFile, outputFile string) error {
+    f, err := os.Open(csvFile)
+    if err != nil {
+        return err
+    }
+    defer f.Close()
+
+    r := csv.NewReader(f)
+    records, err := r.ReadAll()
+    if err != nil {
+        return err
+    }
+
+    p := plot.New()
+    p.Title.Text = "Workload Volatility Over Time"
+    p.X.Label.Text = "Time"
+    p.Y.Label.Text = "Volatility"
+
+    for i := 1; i < len(records[0]); i++ { // Iterate over each workload
+        var pts plotter.XYs
+        for j, record := range records {
+            if j == 0 { // Skip the header
+                continue
+            }
+            t, err := time.Parse(time.RFC3339, record[0]) // Parse the time
+            if err != nil {
+                return err
+            }
+            x := float64(t.Unix())
+            y, err := strconv.ParseFloat(record[i], 64) // Parse the workload volatility
+            if err != nil {
+                return err
+            }
+            pts = append(pts, plotter.XY{X: x, Y: y})
+        }
+
+        line, points, err := plotter.NewLinePoints(pts)
+        if err != nil {
+            return err
+        }
+        line.Color = plotutil.Color(i - 1)
+        points.Shape = draw.CircleGlyph{}
+        p.Add(line, points)
+        p.Legend.Add(records[0][i], line, points)
+    }
+
+    p.X.Tick.Marker = plot.TimeTicks{Format: "15:04"}
+    p.X.Tick.Length = vg.Points(10)
+
+    if err := p.Save(18*vg.Inch, 6*vg.Inch, outputFile); err != nil {
+        return err
+    }
+
+    return nil
+}
+
+func plotWorkloadChanges(csvFile, outputFile string) error {
+    // Open CSV file
+    f, err := os.Open(csvFile)
+    if err != nil {
+        return err
+    }
+    defer f.Close()
+
+    r := csv.NewReader(f)
+    records, err := r.ReadAll()
+    if err != nil {
+        return err
+    }
+
+    // Organize data by workload
+    workloadData := make(map[string]plotter.XYs)
+    for _, record := range records[1:] { // Skipping header
+        timestamp, err := time.Parse(time.RFC3339, record[0])
+        if err != nil {
+            return err
+        }
+        change, err := strconv.ParseFloat(record[2], 64)
+        if err != nil {
+            return err
+        }
+        workload := record[1]
+        workloadData[workload] = append(workloadData[workload], plotter.XY{
+            X: float64(timestamp.Unix()),
+            Y: change,
+        })
+    }
+
+    // Create a plot
+    p := plot.New()
+    if err != nil {
+        return err
+    }
+    p.Title.Text = "Workload Changes Over Time"
+    p.X.Label.Text = "Time"
+    p.Y.Label.Text = "Change"
+    p.X.Tick.Marker = plot.TimeTicks{Format: "15:04"}
+
+    // Define and use a color palette
+    colorPalette := []color.Color{
+        color.RGBA{R: 255, G: 0, B: 0, A: 255}, // Red
+        color.RGBA{G: 255, B: 0, A: 255},       // Green
+        color.RGBA{B: 255, A: 255},             // Blue
+        // ... additional colors ...
+    }
+
+    // Plot each workload with a unique color
+    colorIndex := 0
+    for workload, data := range workloadData {
+        line, points, err := plotter.NewLinePoints(data)
+        if err != nil {
+            return err
+        }
+
+        line.Color = colorPalette[colorIndex%len(colorPalette)]
+        points.Shape = draw.CircleGlyph{}
+        p.Add(line, points)
+        p.Legend.Add(workload, line, points)
+
+        colorIndex++
+    }
+
+    // Save the plot to a file
+    if err := p.Save(24*vg.Inch, 8*vg.Inch, outputFile); err != nil {
+        return err
+    }
+
+    return nil
+}
+
+// readSummedCSV reads a CSV file in the layout written by ExportWorkloadToCSV and returns the
+// dimension names from its header with one series of points per dimension.
+func readSummedCSV(csvFile string) ([]string, []plotter.XYs, error) {
+    f, err := os.Open(csvFile)
+    if err != nil {
+        return nil, nil, err
+    }
+    defer f.Close()
+
+    records, err := csv.NewReader(f).ReadAll()
+    if err != nil {
+        return nil, nil, err
+    }
+    if len(records) == 0 {
+        return nil, nil, fmt.Errorf("%s is empty", csvFile)
+    }
+
+    dimensions := records[0][1:]
+    series := make([]plotter.XYs, len(dimensions))
+    for _, record := range records[1:] {
+        t, err := time.Parse(time.RFC3339, record[0])
+        if err != nil {
+            return nil, nil, err
+        }
+        for i := range dimensions {
+            y, err := strconv.ParseFloat(record[i+1], 64)
+            if err != nil {
+                return nil, nil, err
+            }
+            series[i] = append(series[i], plotter.XY{X: float64(t.Unix()), Y: y})
+        }
+    }
+    return dimensions, series, nil
+}
+
+// plotForecast plots the actual summed load of each dimension followed by its forecast, dashed,
+// and the edges of the forecast's confidence band, dotted. bands names the forecast, lower and
+// upper CSV files written by the forecast command.
+func plotForecast(actualFile string, bands [3]string, outputFile string) error {
+    dimensions, actuals, err := readSummedCSV(actualFile)
+    if err != nil {
+        return err
+    }
+    var forecasts [3]map[string]plotter.XYs
+    for i, bandFile := range bands {
+        bandDimensions, series, err := readSummedCSV(bandFile)
+        if err != nil {
+            return err
+        }
+        forecasts[i] = make(map[string]plotter.XYs, len(bandDimensions))
+        for j, dimension := range bandDimensions {
+            forecasts[i][dimension] = series[j]
+        }
+    }
+
+    p := plot.New()
+    p.Title.Text = "Workload Forecast"
+    p.X.Label.Text = "Time"
+    p.Y.Label.Text = "Workload"
+
+    dashes := [3][]vg.Length{{vg.Points(6), vg.Points(3)}, {vg.Points(1), vg.Points(3)}, {vg.Points(1), vg.Points(3)}}
+    for i, dimension := range dimensions {
+        lineColor := plotutil.Color(i)
+        actual, err := plotter.NewLine(actuals[i])
+        if err != nil {
+            return err
+        }
+        actual.Color = lineColor
+        p.Add(actual)
+        p.Legend.Add(dimension, actual)
+
+        for band, series := range forecasts {
+            if len(series[dimension]) == 0 {
+                continue
+            }
+            line, err := plotter.NewLine(series[dimension])
+            if err != nil {
+                return err
+            }
+            line.Color = lineColor
+            line.Dashes = dashes[band]
+            p.Add(line)
+            if band == 0 {
+                p.Legend.Add(dimension+" forecast", line)
+            }
+        }
+    }
+
+    p.X.Tick.Marker = plot.TimeTicks{Format: "01-02 15:04"}
+    p.X.Tick.Length = vg.Points(10)
+    p.Legend.Top = true
+
+    return p.Save(18*vg.Inch, 6*vg.Inch, outputFile)
+}
+
+// correlationGrid adapts a correlation matrix to plotter.GridXYZ, with row 0 at the top.
+type correlationGrid struct {
+    values [][]float64
+}
+
+func (g correlationGrid) Dims() (c, r int) { return len(g.values), len(g.values) }
+func (g correlationGrid) Z(c, r int) float64 { return g.values[len(g.values)-1-r][c] }
+func (g correlationGrid) X(c int) float64 { return float64(c) }
+func (g correlationGrid) Y(r int) float64 { return float64(r) }
+
+func plotCorrelationHeatmap(csvFile, outputFile string) error {
+    f, err := os.Open(csvFile)
+    if err != nil {
+        return err
+    }
+    defer f.Close()
+
+    r := csv.NewReader(f)
+    records, err := r.ReadAll()
+    if err != nil {
+        return err
+    }
+    if len(records) < 2 {
+        return fmt.Errorf("%s has no workloads", csvFile)
+    }
+
+    // The header row and first column name the workloads.
+    names := records[0][1:]
+    values := make([][]float64, len(names))
+    for i, record := range records[1:] {
+        for _, field := range record[1:] {
+            value, err := strconv.ParseFloat(field, 64)
+            if err != nil {
+                return err
+            }
+            values[i] = append(values[i], value)
+        }
+    }
+
+    colors := moreland.SmoothBlueRed()
+    colors.SetMin(-1)
+    colors.SetMax(1)
+    heatMap := plotter.NewHeatMap(correlationGrid{values: values}, colors.Palette(255))
+    heatMap.Min, heatMap.Max = -1, 1
+    heatMap.NaN = color.Gray{Y: 200}
+
+    p := plot.New()
+    p.Title.Text = "Workload Correlation (blue -1, red +1)"
+    p.Add(heatMap)
+
+    var xTicks, yTicks []plot.Tick
+    for i, name := range names {
+        xTicks = append(xTicks, plot.Tick{Value: float64(i), Label: name})
+        yTicks = append(yTicks, plot.Tick{Value: float64(len(names) - 1 - i), Label: name})
+    }
+    p.X.Tick.Marker = plot.ConstantTicks(xTicks)
+    p.Y.Tick.Marker = plot.ConstantTicks(yTicks)
+    p.X.Tick.Label.Rotation = 1.5708 // Vertical labels so long names don't overlap
+    p.X.Tick.Label.XAlign = draw.XRight
+    p.X.Tick.Label.YAlign = draw.YCenter
+
+    size := vg.Length(len(names))*0.3*vg.Inch + 3*vg.Inch
+    if err := p.Save(size, size, outputFile); err != nil {
+        return err
+    }
+    return nil
+}
+
+func getUniqueWorkloads(csvFile string) ([]string, error) {
+    f, err := os.Open(csvFile)
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+
+    r := csv.NewReader(f)
+    records, err := r.ReadAll()
+    if err != nil {
+        return nil, err
+    }
+
+    uniqueWorkloads := make(map[string]struct{})
+    for _, record := range records[1:] { // Skipping header
+        workload := record[1]
+        uniqueWorkloads[workload] = struct{}{}
+    }
+
+    var workloads []string
+    for workload := range uniqueWorkloads {
+        workloads = append(workloads, workload)
+    }
+    return workloads, nil
+}
+
+func generateColor(index int) color.Color {
+    // Base colors
+    baseColors := []color.RGBA{
+        {R: 255, G: 0, B: 0, A: 255}, // Red
+        {G: 255, B: 0, A: 255},       // Green
+        {B: 255, A: 255},             // Blue
+        {R: 255, G: 255, A: 255},     // Yellow
+        {R: 255, B: 255, A: 255},     // Magenta
+        {G: 255, B: 255, A: 255},     // Cyan
+    }
+
+    // Select a base color and modify it slightly
+    base := baseColors[index%len(baseColors)]
+    return color.RGBA{
+        R: base.R + uint8(rand.Intn(55)),
+        G: base.G + uint8(rand.Intn(55)),
+        B: base.B + uint8(rand.Intn(55)),
+        A: 255,
+    }
+}
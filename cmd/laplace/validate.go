@@ -0,0 +1,38 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+
+    "github.com/codyshoward/laplace"
+)
+
+// runValidate checks the workload files named by --in and writes the problems found to
+// validation.json. It fails when any problem is found, so a pipeline can stop on bad exports.
+func runValidate(args []string) error {
+    flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+    opts := addWorkloadFlags(flags)
+    allowNegative := flags.Bool("allow-negative", false, "accept negative load values, e.g. for files written by generate")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+
+    reports, err := opts.reports()
+    if err != nil {
+        return err
+    }
+    files, err := opts.files()
+    if err != nil {
+        return err
+    }
+
+    report := laplace.ValidateFiles(files, laplace.ValidationOptions{Format: opts.format, AllowNegative: *allowNegative}, opts.workers)
+    laplace.PrintValidationReport(report)
+    if err := laplace.WriteValidationReportToFile(report, reports.validation()); err != nil {
+        return err
+    }
+    if len(report.Issues) > 0 {
+        return fmt.Errorf("%d problems found, see %s", len(report.Issues), reports.validation())
+    }
+    return nil
+}
@@ -0,0 +1,61 @@
+package laplace
+
+import (
+    "encoding/json"
+    "io"
+    "os"
+)
+
+// LoadData loads workload data from a JSON file and returns it as a Data struct.
+func LoadData(filename string) (*Data, error) {
+    // Open the file for reading.
+    file, err := os.Open(filename)
+    if err != nil {
+        return nil, err // Return an error if file opening fails.
+    }
+    defer file.Close() // Ensure the file is closed after the function execution.
+
+    return DecodeData(file)
+}
+
+// LoadFiles loads every file on up to workers goroutines (one per CPU if workers is 0) and returns
+// their workloads in file order. Each file is read in format, or in the format implied by its
+// extension when format is empty (see FileFormat). Files that fail to load are left out; errs holds
+// the error of each file by index, nil for those that loaded.
+func LoadFiles(filenames []string, format string, workers int) (data *Data, errs []error) {
+    loaded := make([]*Data, len(filenames))
+    errs = make([]error, len(filenames))
+    parallelFor(len(filenames), workers, func(i int) {
+        loaded[i], errs[i] = LoadFile(filenames[i], format)
+    })
+
+    data = &Data{}
+    for _, fileData := range loaded {
+        if fileData != nil {
+            data.Workloads = append(data.Workloads, fileData.Workloads...)
+        }
+    }
+    return data, errs
+}
+
+// DecodeData decodes workload data in the {"workloads": [...]} JSON layout from r.
+func DecodeData(r io.Reader) (*Data, error) {
+    var data Data
+
+     // Decode the JSON data into the Data struct.
+    jsonDecoder := json.NewDecoder(r)
+    if err := jsonDecoder.Decode(&data); err != nil {
+        return nil, err // Return an error if JSON decoding fails.
+    }
+
+    return &data, nil // Return the loaded data.
+}
+
+// SerializeToJson encodes the workload in the {"workloads": [...]} layout read by LoadData.
+func (w *Workload) SerializeToJson() ([]byte, error) {
+    data, err := json.MarshalIndent(Data{Workloads: []Workload{*w}}, "", "    ")
+    if err != nil {
+        return nil, err
+    }
+    return data, nil
+}
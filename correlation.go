@@ -0,0 +1,249 @@
+package laplace
+
+import (
+    "encoding/csv"
+    "fmt"
+    "math"
+    "os"
+    "sort"
+    "strconv"
+)
+
+// TotalDimension names the combined load of every dimension in correlation and peak results.
+const TotalDimension = "total"
+
+// Correlation methods supported by CalculateCorrelations.
+const (
+    Pearson  = "pearson"
+    Spearman = "spearman"
+)
+
+// CorrelationMatrix holds the correlation between every pair of workloads' load series in one
+// dimension. Values[i][j] correlates Workloads[i] with Workloads[j] over the timestamps both
+// series share, and is NaN when either series is constant over them or they share fewer than
+// two timestamps.
+type CorrelationMatrix struct {
+    Dimension string
+    Method    string
+    Workloads []string
+    Values    [][]float64
+}
+
+// CorrelatedPair is the correlation between two workloads.
+type CorrelatedPair struct {
+    A           string
+    B           string
+    Correlation float64
+}
+
+// CalculateCorrelations returns the Pearson and Spearman correlation matrices of the workloads
+// for each load dimension and for TotalDimension, the sum of every dimension. The workloads are
+// laid out once on the axis of a SeriesIndex, and the rows of the matrices are correlated on up to
+// workers goroutines (one per CPU if workers is 0).
+func CalculateCorrelations(workloads []Workload, workers int) []CorrelationMatrix {
+    names := make([]string, len(workloads))
+    for i, workload := range workloads {
+        names[i] = workload.Name
+    }
+
+    index := NewSeriesIndex(workloads, workers)
+    dimensions := append(append([]string(nil), index.Dimensions...), TotalDimension)
+    var matrices []CorrelationMatrix
+    for _, dimension := range dimensions {
+        rows := make([]correlationRow, len(workloads))
+        parallelFor(len(workloads), workers, func(w int) {
+            rows[w] = newCorrelationRow(index, w, dimension)
+        })
+        for _, method := range []string{Pearson, Spearman} {
+            matrices = append(matrices, correlationMatrix(dimension, method, names, rows, workers))
+        }
+    }
+    return matrices
+}
+
+// correlationRow is one workload's load in one dimension laid out on the index axis.
+type correlationRow struct {
+    values  []float64
+    present []bool
+    // complete is set when the workload reports every timestamp of the axis, in which case ranks
+    // holds the rank of each value for Spearman and every pair of complete rows is correlated
+    // without realigning.
+    complete bool
+    ranks    []float64
+}
+
+func newCorrelationRow(index *SeriesIndex, w int, dimension string) correlationRow {
+    values, present := index.row(w, dimension)
+    row := correlationRow{values: values, present: present, complete: true}
+    for _, reported := range present {
+        if !reported {
+            row.complete = false
+            break
+        }
+    }
+    if row.complete {
+        row.ranks = ranks(values)
+    }
+    return row
+}
+
+func correlationMatrix(dimension, method string, names []string, rows []correlationRow, workers int) CorrelationMatrix {
+    values := make([][]float64, len(rows))
+    for i := range values {
+        values[i] = make([]float64, len(rows))
+    }
+    // Row i fills values[i][j] and values[j][i] for j >= i, so no two rows write the same cell.
+    parallelFor(len(rows), workers, func(i int) {
+        for j := i; j < len(rows); j++ {
+            correlation := correlateRows(&rows[i], &rows[j], method)
+            values[i][j], values[j][i] = correlation, correlation
+        }
+    })
+    return CorrelationMatrix{Dimension: dimension, Method: method, Workloads: names, Values: values}
+}
+
+// correlateRows correlates two rows over the timestamps both report.
+func correlateRows(a, b *correlationRow, method string) float64 {
+    if a.complete && b.complete {
+        if method == Spearman {
+            return pearsonCorrelation(a.ranks, b.ranks)
+        }
+        return pearsonCorrelation(a.values, b.values)
+    }
+
+    var x, y []float64
+    for p := range a.values {
+        if a.present[p] && b.present[p] {
+            x = append(x, a.values[p])
+            y = append(y, b.values[p])
+        }
+    }
+    if method == Spearman {
+        return spearmanCorrelation(x, y)
+    }
+    return pearsonCorrelation(x, y)
+}
+
+func pearsonCorrelation(x, y []float64) float64 {
+    if len(x) < 2 || len(x) != len(y) {
+        return math.NaN()
+    }
+
+    var meanX, meanY float64
+    for i := range x {
+        meanX += x[i]
+        meanY += y[i]
+    }
+    meanX /= float64(len(x))
+    meanY /= float64(len(y))
+
+    var covariance, varianceX, varianceY float64
+    for i := range x {
+        dx, dy := x[i]-meanX, y[i]-meanY
+        covariance += dx * dy
+        varianceX += dx * dx
+        varianceY += dy * dy
+    }
+    if varianceX == 0 || varianceY == 0 {
+        return math.NaN()
+    }
+    return covariance / math.Sqrt(varianceX*varianceY)
+}
+
+// spearmanCorrelation is the Pearson correlation of the ranks of x and y.
+func spearmanCorrelation(x, y []float64) float64 {
+    return pearsonCorrelation(ranks(x), ranks(y))
+}
+
+// ranks returns the 1-based rank of each value, giving tied values the average of their ranks.
+func ranks(values []float64) []float64 {
+    order := make([]int, len(values))
+    for i := range order {
+        order[i] = i
+    }
+    sort.SliceStable(order, func(a, b int) bool {
+        return values[order[a]] < values[order[b]]
+    })
+
+    ranked := make([]float64, len(values))
+    for start := 0; start < len(order); {
+        end := start + 1
+        for end < len(order) && values[order[end]] == values[order[start]] {
+            end++
+        }
+        rank := float64(start+end+1) / 2 // Average of the 1-based ranks start+1..end
+        for k := start; k < end; k++ {
+            ranked[order[k]] = rank
+        }
+        start = end
+    }
+    return ranked
+}
+
+// ExtremePairs returns up to n of the most positively and the most negatively correlated pairs of
+// distinct workloads in the matrix, skipping undefined (NaN) correlations. A pair appears in at
+// most one list, and uncorrelated pairs in neither.
+func (m CorrelationMatrix) ExtremePairs(n int) (mostCorrelated, mostAntiCorrelated []CorrelatedPair) {
+    var pairs []CorrelatedPair
+    for i := range m.Workloads {
+        for j := i + 1; j < len(m.Workloads); j++ {
+            if math.IsNaN(m.Values[i][j]) {
+                continue
+            }
+            pairs = append(pairs, CorrelatedPair{A: m.Workloads[i], B: m.Workloads[j], Correlation: m.Values[i][j]})
+        }
+    }
+    sort.SliceStable(pairs, func(i, j int) bool {
+        return pairs[i].Correlation > pairs[j].Correlation
+    })
+
+    for i := 0; i < len(pairs) && len(mostCorrelated) < n && pairs[i].Correlation > 0; i++ {
+        mostCorrelated = append(mostCorrelated, pairs[i])
+    }
+    for i := len(pairs) - 1; i >= 0 && len(mostAntiCorrelated) < n && pairs[i].Correlation < 0; i-- {
+        mostAntiCorrelated = append(mostAntiCorrelated, pairs[i])
+    }
+    return mostCorrelated, mostAntiCorrelated
+}
+
+// PrintCorrelationSummary prints the n most correlated and anti-correlated pairs of each matrix.
+func PrintCorrelationSummary(matrices []CorrelationMatrix, n int) {
+    for _, matrix := range matrices {
+        mostCorrelated, mostAntiCorrelated := matrix.ExtremePairs(n)
+        fmt.Printf("\nMost Correlated Workloads (%s, %s):\n", matrix.Dimension, matrix.Method)
+        for _, pair := range mostCorrelated {
+            fmt.Printf("%s & %s: %.3f\n", pair.A, pair.B, pair.Correlation)
+        }
+        fmt.Printf("\nMost Anti-Correlated Workloads (%s, %s):\n", matrix.Dimension, matrix.Method)
+        for _, pair := range mostAntiCorrelated {
+            fmt.Printf("%s & %s: %.3f\n", pair.A, pair.B, pair.Correlation)
+        }
+    }
+}
+
+// WriteCorrelationMatrixToFile writes the matrix as CSV with a header row and a first column of
+// workload names.
+func WriteCorrelationMatrixToFile(matrix CorrelationMatrix, outputFile string) error {
+    file, err := os.Create(outputFile)
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+
+    writer := csv.NewWriter(file)
+    defer writer.Flush()
+
+    if err := writer.Write(append([]string{"Workload"}, matrix.Workloads...)); err != nil {
+        return err
+    }
+    for i, name := range matrix.Workloads {
+        row := []string{name}
+        for _, value := range matrix.Values[i] {
+            row = append(row, strconv.FormatFloat(value, 'f', 4, 64))
+        }
+        if err := writer.Write(row); err != nil {
+            return err
+        }
+    }
+    return nil
+}
@@ -0,0 +1,365 @@
+package laplace
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "sort"
+    "strings"
+    "time"
+)
+
+// Pricing maps each load dimension to the price of one unit of that load, e.g. $/cpu-minute
+// or $/GB-egress. Dimensions without an entry in UnitPrices are charged DefaultUnitPrice.
+//
+// A dimension listed in Rules is priced by its volume tiers instead, and its time windows scale
+// the price of the values whose timestamps fall inside them (e.g. off-peak hours or weekends).
+type Pricing struct {
+    Currency         string                  `json:"currency"`
+    DefaultUnitPrice float64                 `json:"defaultUnitPrice"`
+    UnitPrices       map[string]float64      `json:"unitPrices"`
+    Rules            map[string]PricingRule  `json:"rules"`
+    Timezone         string                  `json:"timezone"`
+    location         *time.Location
+}
+
+// PricingRule prices one load dimension by cumulative usage tiers and time-of-day windows.
+// Without tiers the dimension's unit price applies to every unit.
+type PricingRule struct {
+    Tiers   []PriceTier   `json:"tiers"`
+    Windows []PriceWindow `json:"windows"`
+}
+
+// PriceTier charges UnitPrice for the units of a workload's cumulative usage, in timestamp order,
+// up to UpTo. Tiers are listed in ascending order; an UpTo of 0 marks the last, unbounded tier.
+type PriceTier struct {
+    Name      string  `json:"name"`
+    UpTo      float64 `json:"upTo"`
+    UnitPrice float64 `json:"unitPrice"`
+}
+
+// PriceWindow multiplies the price of values whose timestamp falls on one of Weekdays (all days
+// when empty) between StartHour inclusive and EndHour exclusive. A window may wrap past midnight,
+// e.g. 22 to 6. The first matching window applies. A Multiplier of 0, as when it is left out,
+// keeps the price unchanged.
+type PriceWindow struct {
+    Name       string   `json:"name"`
+    Weekdays   []string `json:"weekdays"`
+    StartHour  int      `json:"startHour"`
+    EndHour    int      `json:"endHour"`
+    Multiplier float64  `json:"multiplier"`
+    days       map[time.Weekday]bool
+}
+
+// CostBucket identifies the dimension, tier and time window a share of a workload's cost came from.
+type CostBucket struct {
+    Dimension string
+    Tier      string
+    Window    string
+}
+
+const (
+    // baseTier labels cost charged at a dimension's flat unit price.
+    baseTier = "base"
+    // standardWindow labels cost whose timestamp falls in no pricing window.
+    standardWindow = "standard"
+)
+
+// FlatPricing charges one unit of currency per unit of load in every dimension, so a workload's
+// cost equals its total load.
+func FlatPricing() Pricing {
+    return Pricing{DefaultUnitPrice: 1}
+}
+
+// LoadPricing loads a pricing configuration from a JSON file such as
+//
+//    {"currency": "USD", "unitPrices": {"cpu": 0.0008, "net_egress": 0.09},
+//     "rules": {"net_egress": {
+//         "tiers": [{"name": "first 10TB", "upTo": 10240, "unitPrice": 0.09}, {"name": "over 10TB", "unitPrice": 0.085}],
+//         "windows": [{"name": "off-peak", "startHour": 22, "endHour": 6, "multiplier": 0.5}]}}}
+func LoadPricing(filename string) (Pricing, error) {
+    var pricing Pricing
+
+    file, err := os.Open(filename)
+    if err != nil {
+        return pricing, err
+    }
+    defer file.Close()
+
+    if err := json.NewDecoder(file).Decode(&pricing); err != nil {
+        return pricing, fmt.Errorf("decoding pricing %s: %w", filename, err)
+    }
+    if err := pricing.prepare(); err != nil {
+        return pricing, fmt.Errorf("pricing %s: %w", filename, err)
+    }
+    return pricing, nil
+}
+
+// prepare validates the pricing and resolves its timezone and window weekdays.
+func (p *Pricing) prepare() error {
+    for dimension, price := range p.UnitPrices {
+        if price < 0 {
+            return fmt.Errorf("negative unit price %v for %s", price, dimension)
+        }
+    }
+
+    if p.Timezone != "" {
+        location, err := time.LoadLocation(p.Timezone)
+        if err != nil {
+            return err
+        }
+        p.location = location
+    }
+
+    for dimension, rule := range p.Rules {
+        for i, tier := range rule.Tiers {
+            if tier.UnitPrice < 0 {
+                return fmt.Errorf("%s: tier %d has a negative unit price", dimension, i)
+            }
+            last := i == len(rule.Tiers)-1
+            if tier.UpTo == 0 && !last {
+                return fmt.Errorf("%s: only the last tier may be unbounded", dimension)
+            }
+            if i > 0 && tier.UpTo != 0 && tier.UpTo <= rule.Tiers[i-1].UpTo {
+                return fmt.Errorf("%s: tiers must be in ascending order of upTo", dimension)
+            }
+            if tier.Name == "" {
+                rule.Tiers[i].Name = tierLabel(rule.Tiers, i)
+            }
+        }
+
+        for i := range rule.Windows {
+            window := &rule.Windows[i]
+            if window.StartHour < 0 || window.StartHour > 23 || window.EndHour < 0 || window.EndHour > 24 {
+                return fmt.Errorf("%s: window %d hours must be within 0-24", dimension, i)
+            }
+            if window.Multiplier < 0 {
+                return fmt.Errorf("%s: window %d has a negative multiplier", dimension, i)
+            }
+            if window.Name == "" {
+                window.Name = fmt.Sprintf("%02d-%02dh", window.StartHour, window.EndHour)
+            }
+            window.days = make(map[time.Weekday]bool)
+            for _, name := range window.Weekdays {
+                day, err := parseWeekday(name)
+                if err != nil {
+                    return fmt.Errorf("%s: window %s: %w", dimension, window.Name, err)
+                }
+                window.days[day] = true
+            }
+        }
+        p.Rules[dimension] = rule
+    }
+    return nil
+}
+
+// tierLabel names an unnamed tier after the usage range it covers, e.g. "1000-5000".
+func tierLabel(tiers []PriceTier, i int) string {
+    var from float64
+    if i > 0 {
+        from = tiers[i-1].UpTo
+    }
+    if tiers[i].UpTo == 0 {
+        return fmt.Sprintf("%g+", from)
+    }
+    return fmt.Sprintf("%g-%g", from, tiers[i].UpTo)
+}
+
+func parseWeekday(name string) (time.Weekday, error) {
+    for day := time.Sunday; day <= time.Saturday; day++ {
+        full := day.String()
+        if strings.EqualFold(name, full) || strings.EqualFold(name, full[:3]) {
+            return day, nil
+        }
+    }
+    return 0, fmt.Errorf("unknown weekday %q", name)
+}
+
+// UnitPrice returns the flat price of one unit of load in the given dimension.
+func (p Pricing) UnitPrice(dimension string) float64 {
+    if price, ok := p.UnitPrices[dimension]; ok {
+        return price
+    }
+    return p.DefaultUnitPrice
+}
+
+// contains reports whether the timestamp falls inside the window.
+func (w PriceWindow) contains(timestamp time.Time) bool {
+    if len(w.Weekdays) > 0 && !w.onDay(timestamp.Weekday()) {
+        return false
+    }
+    hour := timestamp.Hour()
+    switch {
+    case w.StartHour == w.EndHour:
+        return true
+    case w.StartHour < w.EndHour:
+        return hour >= w.StartHour && hour < w.EndHour
+    default: // Wraps past midnight
+        return hour >= w.StartHour || hour < w.EndHour
+    }
+}
+
+// onDay reports whether the window applies on the given weekday. Windows built in code rather
+// than loaded by LoadPricing have their weekday names parsed here.
+func (w PriceWindow) onDay(day time.Weekday) bool {
+    if w.days != nil {
+        return w.days[day]
+    }
+    for _, name := range w.Weekdays {
+        if parsed, err := parseWeekday(name); err == nil && parsed == day {
+            return true
+        }
+    }
+    return false
+}
+
+// window returns the name and price multiplier of the first window containing the timestamp.
+func (r PricingRule) window(timestamp time.Time) (string, float64) {
+    for _, window := range r.Windows {
+        if window.contains(timestamp) {
+            return window.Name, window.multiplier()
+        }
+    }
+    return standardWindow, 1
+}
+
+// multiplier returns the window's price multiplier, treating an unset one as 1.
+func (w PriceWindow) multiplier() float64 {
+    if w.Multiplier == 0 {
+        return 1
+    }
+    return w.Multiplier
+}
+
+// priceLoad charges every value of one dimension's series in timestamp order, splitting each value
+// across the volume tiers its cumulative usage spans, and adds the cost of each tier and window to
+// breakdown. The series itself is left in its order.
+func (p Pricing) priceLoad(dimension string, load []TimedValue, breakdown map[CostBucket]float64) float64 {
+    sorted := append([]TimedValue(nil), load...)
+    sort.SliceStable(sorted, func(i, j int) bool {
+        return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+    })
+
+    meter := p.newCostMeter(dimension, breakdown)
+    for _, timedValue := range sorted {
+        meter.add(timedValue)
+    }
+    return meter.total
+}
+
+// costMeter prices one load series a value at a time, carrying the cumulative usage the tiers are
+// applied to. Values must be added in timestamp order for tiers to be applied correctly.
+type costMeter struct {
+    pricing    Pricing
+    dimension  string
+    rule       PricingRule
+    hasRule    bool
+    breakdown  map[CostBucket]float64
+    cumulative float64
+    total      float64
+}
+
+// newCostMeter returns a meter that records the cost of each value of the dimension in breakdown.
+func (p Pricing) newCostMeter(dimension string, breakdown map[CostBucket]float64) *costMeter {
+    rule, hasRule := p.Rules[dimension]
+    return &costMeter{pricing: p, dimension: dimension, rule: rule, hasRule: hasRule, breakdown: breakdown}
+}
+
+// add charges a single value and adds it to the meter's usage.
+func (m *costMeter) add(timedValue TimedValue) {
+    window, multiplier := standardWindow, 1.0
+    if m.hasRule {
+        timestamp := timedValue.Timestamp
+        if m.pricing.location != nil {
+            timestamp = timestamp.In(m.pricing.location)
+        }
+        window, multiplier = m.rule.window(timestamp)
+    }
+
+    charge := func(tier string, units, unitPrice float64) {
+        cost := units * unitPrice * multiplier
+        m.breakdown[CostBucket{Dimension: m.dimension, Tier: tier, Window: window}] += cost
+        m.total += cost
+    }
+
+    tiers := m.rule.Tiers
+    if len(tiers) == 0 {
+        charge(baseTier, timedValue.Value, m.pricing.UnitPrice(m.dimension))
+        return
+    }
+
+    // Negative values refund at the tier of the current usage rather than spanning tiers.
+    remaining := timedValue.Value
+    if remaining < 0 {
+        tier := tiers[tierIndex(tiers, m.cumulative)]
+        charge(tier.Name, remaining, tier.UnitPrice)
+        m.cumulative += remaining
+        return
+    }
+    for remaining > 0 {
+        i := tierIndex(tiers, m.cumulative)
+        tier := tiers[i]
+        units := remaining
+        if tier.UpTo != 0 && i < len(tiers)-1 && m.cumulative+units > tier.UpTo {
+            units = tier.UpTo - m.cumulative
+        }
+        charge(tier.Name, units, tier.UnitPrice)
+        m.cumulative += units
+        remaining -= units
+    }
+}
+
+// tierIndex returns the tier containing the given cumulative usage. Usage beyond the last tier's
+// bound is charged at the last tier.
+func tierIndex(tiers []PriceTier, cumulative float64) int {
+    for i, tier := range tiers {
+        if tier.UpTo == 0 || cumulative < tier.UpTo {
+            return i
+        }
+    }
+    return len(tiers) - 1
+}
+
+// calculateCosts prices each of the workload's load series and returns the cost per dimension,
+// recording the share of each tier and time window in workload.CostBreakdown.
+func calculateCosts(workload *Workload, pricing Pricing) map[string]float64 {
+    costs := make(map[string]float64, len(workload.Loads))
+    workload.CostBreakdown = make(map[CostBucket]float64)
+    for dimension, load := range workload.Loads {
+        costs[dimension] = pricing.priceLoad(dimension, load, workload.CostBreakdown)
+    }
+    return costs
+}
+
+// calculateTotalCost sums the workload's cost per dimension in sorted order, so the total doesn't
+// depend on map iteration.
+func calculateTotalCost(workload *Workload) float64 {
+    dimensions := make([]string, 0, len(workload.Costs))
+    for dimension := range workload.Costs {
+        dimensions = append(dimensions, dimension)
+    }
+    sort.Strings(dimensions)
+    var totalCost float64
+    for _, dimension := range dimensions {
+        totalCost += workload.Costs[dimension]
+    }
+    return totalCost
+}
+
+// SortedCostBuckets returns the buckets of a cost breakdown ordered by dimension, tier and window.
+func SortedCostBuckets(breakdown map[CostBucket]float64) []CostBucket {
+    buckets := make([]CostBucket, 0, len(breakdown))
+    for bucket := range breakdown {
+        buckets = append(buckets, bucket)
+    }
+    sort.Slice(buckets, func(i, j int) bool {
+        if buckets[i].Dimension != buckets[j].Dimension {
+            return buckets[i].Dimension < buckets[j].Dimension
+        }
+        if buckets[i].Tier != buckets[j].Tier {
+            return buckets[i].Tier < buckets[j].Tier
+        }
+        return buckets[i].Window < buckets[j].Window
+    })
+    return buckets
+}
@@ -0,0 +1,9 @@
+// Package laplace holds the workload model shared by the Laplace generator, analyzer and plotter,
+// along with its JSON codec and the statistics computed over it.
+//
+// A workload is a named set of load dimensions (e.g. "cpu", "net_egress"), each a series of
+// timestamped values, plus the value the workload generated. Data is decoded from files shaped as
+// {"workloads": [...]} with LoadData, or from long-format CSV and JSON Lines exports with LoadFile
+// and the WorkloadReader of their format, or imported from Prometheus with ImportPrometheus, and
+// analyzed with CalculateWorkloadStats.
+package laplace
@@ -0,0 +1,129 @@
+package laplace
+
+import (
+    "encoding/csv"
+    "encoding/json"
+    "fmt"
+    "os"
+    "sort"
+)
+
+// DefaultEfficiencyFlagRatio flags workloads returning less than half their fair share of value
+// for the share of cost they consume.
+const DefaultEfficiencyFlagRatio = 0.5
+
+// WorkloadEfficiency compares the cost a workload consumes with the value it generates.
+//
+// EfficiencyGap is the relative value generated minus the relative cost, in percentage points; a
+// workload returning exactly its share of the cost scores 0. EfficiencyRatio is relative value over
+// relative cost, where 1 is a fair share; it is 0 for workloads without cost.
+type WorkloadEfficiency struct {
+    Rank                   int     `json:"rank"`
+    Name                   string  `json:"name"`
+    TotalCost              float64 `json:"totalCost"`
+    ValueGenerated         float64 `json:"valueGenerated"`
+    RelativeCost           float64 `json:"relativeCost"`
+    RelativeValueGenerated float64 `json:"relativeValueGenerated"`
+    ValuePerCost           float64 `json:"valuePerCost"`
+    EfficiencyGap          float64 `json:"efficiencyGap"`
+    EfficiencyRatio        float64 `json:"efficiencyRatio"`
+    Flagged                bool    `json:"flagged"`
+}
+
+// RankEfficiency ranks workloads from the most to the least value returned for their cost, using
+// the statistics from CalculateWorkloadStats. Workloads whose EfficiencyRatio is below flagRatio
+// are flagged as consuming much more than they return.
+func RankEfficiency(workloads []Workload, flagRatio float64) []WorkloadEfficiency {
+    efficiencies := make([]WorkloadEfficiency, 0, len(workloads))
+    for _, workload := range workloads {
+        efficiency := WorkloadEfficiency{
+            Name:                   workload.Name,
+            TotalCost:              workload.TotalCost,
+            ValueGenerated:         workload.ValueGenerated,
+            RelativeCost:           workload.RelativeCost,
+            RelativeValueGenerated: workload.RelativeValueGenerated,
+            EfficiencyGap:          workload.RelativeValueGenerated - workload.RelativeCost,
+        }
+        if workload.TotalCost > 0 {
+            efficiency.ValuePerCost = workload.ValueGenerated / workload.TotalCost
+        }
+        if workload.RelativeCost > 0 {
+            efficiency.EfficiencyRatio = workload.RelativeValueGenerated / workload.RelativeCost
+            efficiency.Flagged = efficiency.EfficiencyRatio < flagRatio
+        }
+        efficiencies = append(efficiencies, efficiency)
+    }
+
+    // Rank by gap so workloads without cost still order sensibly; break ties by name.
+    sort.Slice(efficiencies, func(i, j int) bool {
+        if efficiencies[i].EfficiencyGap != efficiencies[j].EfficiencyGap {
+            return efficiencies[i].EfficiencyGap > efficiencies[j].EfficiencyGap
+        }
+        return efficiencies[i].Name < efficiencies[j].Name
+    })
+    for i := range efficiencies {
+        efficiencies[i].Rank = i + 1
+    }
+    return efficiencies
+}
+
+// PrintEfficiencyReport prints the efficiency ranking, marking flagged workloads.
+func PrintEfficiencyReport(efficiencies []WorkloadEfficiency) {
+    fmt.Println("\nValue Efficiency Ranking (relative value - relative cost):")
+    for _, efficiency := range efficiencies {
+        marker := ""
+        if efficiency.Flagged {
+            marker = "  <- consumes much more than it returns"
+        }
+        fmt.Printf("%d. Workload: %s, Gap: %+.2f pts, Ratio: %.2f, Value per Cost: %.4f%s\n",
+            efficiency.Rank, efficiency.Name, efficiency.EfficiencyGap, efficiency.EfficiencyRatio, efficiency.ValuePerCost, marker)
+    }
+}
+
+// WriteEfficiencyToFile writes the efficiency ranking to a CSV file.
+func WriteEfficiencyToFile(efficiencies []WorkloadEfficiency, outputFile string) error {
+    file, err := os.Create(outputFile)
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+
+    writer := csv.NewWriter(file)
+    defer writer.Flush()
+
+    header := []string{"Rank", "Workload", "Total Cost", "Value Generated", "Relative Cost", "Relative Value Generated", "Value Per Cost", "Efficiency Gap", "Efficiency Ratio", "Flagged"}
+    if err := writer.Write(header); err != nil {
+        return err
+    }
+
+    for _, efficiency := range efficiencies {
+        record := []string{
+            fmt.Sprintf("%d", efficiency.Rank),
+            efficiency.Name,
+            fmt.Sprintf("%.2f", efficiency.TotalCost),
+            fmt.Sprintf("%.2f", efficiency.ValueGenerated),
+            fmt.Sprintf("%.2f", efficiency.RelativeCost),
+            fmt.Sprintf("%.2f", efficiency.RelativeValueGenerated),
+            fmt.Sprintf("%.4f", efficiency.ValuePerCost),
+            fmt.Sprintf("%.2f", efficiency.EfficiencyGap),
+            fmt.Sprintf("%.2f", efficiency.EfficiencyRatio),
+            fmt.Sprintf("%t", efficiency.Flagged),
+        }
+        if err := writer.Write(record); err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// WriteEfficiencyToJSON writes the efficiency ranking to a JSON file as {"workloads": [...]}.
+func WriteEfficiencyToJSON(efficiencies []WorkloadEfficiency, outputFile string) error {
+    data, err := json.MarshalIndent(struct {
+        Workloads []WorkloadEfficiency `json:"workloads"`
+    }{Workloads: efficiencies}, "", "    ")
+    if err != nil {
+        return err
+    }
+    return os.WriteFile(outputFile, data, 0o644)
+}
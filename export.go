@@ -0,0 +1,275 @@
+package laplace
+
+import (
+    "encoding/csv"
+    "fmt"
+    "io"
+    "os"
+    "time"
+)
+
+// ExportWorkloadToCSV exports the summed workload data to a CSV file with one column per dimension.
+func ExportWorkloadToCSV(workloads []SummedWorkload, dimensions []string, filename string) error {
+    // CSV file creation and error handling remains the same.
+
+    // Create a CSV file
+    file, err := os.Create(filename)
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+
+    writer := csv.NewWriter(file)
+    defer writer.Flush()
+    // Write header
+    if err := writer.Write(append([]string{"Timestamp"}, dimensions...)); err != nil {
+        return err
+    }
+    // Write data
+    for _, workload := range workloads {
+        row := []string{workload.Timestamp.Format(time.RFC3339)}
+        for _, dimension := range dimensions {
+            row = append(row, fmt.Sprintf("%f", workload.Totals[dimension]))
+        }
+
+        if err := writer.Write(row); err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// WriteVolatilityToFile reads the summed loads written by ExportWorkloadToCSV and writes the
+// standard deviation of every dimension over consecutive intervals, keyed by interval start.
+func WriteVolatilityToFile(csvInputFile, outputFile string, interval time.Duration) error {
+    if interval <= 0 {
+        return fmt.Errorf("interval must be positive, got %v", interval)
+    }
+
+    f, err := os.Open(csvInputFile)
+    if err != nil {
+        return err
+    }
+    defer f.Close()
+
+    r := csv.NewReader(f)
+    records, err := r.ReadAll()
+    if err != nil {
+        return err
+    }
+
+    outFile, err := os.Create(outputFile)
+    if err != nil {
+        return err
+    }
+    defer outFile.Close()
+
+    writer := csv.NewWriter(outFile)
+    defer writer.Flush()
+
+    // Write header, naming each column after the dimension in the input header
+    header := []string{"Time"}
+    for i := 1; i < len(records[0]); i++ {
+        header = append(header, fmt.Sprintf("Volatility %s", records[0][i]))
+    }
+    if err := writer.Write(header); err != nil {
+        return err
+    }
+
+    // Group the rows by interval, skipping the input header row
+    intervals, err := groupRowsByInterval(records[1:], interval)
+    if err != nil {
+        return err
+    }
+
+    // Calculate and write volatilities
+    for _, rows := range intervals {
+        var row []string
+        row = append(row, rows[0][0]) // Add interval start timestamp
+        for j := 1; j < len(records[0]); j++ {
+            volatility, err := calculateVolatilityOfRows(rows, j)
+            if err != nil {
+                return err
+            }
+            row = append(row, fmt.Sprintf("%.2f", volatility))
+        }
+        if err := writer.Write(row); err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// groupRowsByInterval groups CSV rows, whose first column is an RFC 3339 timestamp, into the
+// same consecutive intervals groupByInterval uses for load series.
+func groupRowsByInterval(rows [][]string, interval time.Duration) ([][][]string, error) {
+    // Carry each row's index as the value so the groups can be mapped back to rows.
+    indexed := make([]TimedValue, len(rows))
+    for i, row := range rows {
+        timestamp, err := time.Parse(time.RFC3339, row[0])
+        if err != nil {
+            return nil, err
+        }
+        indexed[i] = TimedValue{Timestamp: timestamp, Value: float64(i)}
+    }
+
+    var groups [][][]string
+    for _, group := range groupByInterval(indexed, interval) {
+        var groupRows [][]string
+        for _, timedValue := range group {
+            groupRows = append(groupRows, rows[int(timedValue.Value)])
+        }
+        groups = append(groups, groupRows)
+    }
+    return groups, nil
+}
+
+// WriteWorkloadVolatilityToFile writes the standard deviation of each workload's interval-averaged
+// combined load and its normalized volatility metrics averaged across dimensions, as calculated by
+// CalculateWorkloadStats, with the tier set by AssignVolatilityTiers.
+func WriteWorkloadVolatilityToFile(data *Data, outputFile string) error {
+    file, err := os.Create(outputFile)
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+
+    writer := csv.NewWriter(file)
+    defer writer.Flush()
+
+    // Write the header
+    if err := writer.Write([]string{"Workload", "Volatility", "CV", "PeakToMean", "IQRToMean", "Tier"}); err != nil {
+        return err
+    }
+
+    for _, workload := range data.Workloads {
+        record := []string{
+            workload.Name,
+            fmt.Sprintf("%.2f", workload.CombinedVolatility),
+            fmt.Sprintf("%.2f", WorkloadVolatilityMetric(workload, CVMetric)),
+            fmt.Sprintf("%.2f", WorkloadVolatilityMetric(workload, PeakToMeanMetric)),
+            fmt.Sprintf("%.2f", WorkloadVolatilityMetric(workload, IQRToMeanMetric)),
+            workload.VolatilityTier,
+        }
+
+        if err := writer.Write(record); err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// WriteWorkloadIntervalVolatilityToFile writes the change in each workload's combined load from one interval to the next.
+// The changes are calculated on up to workers goroutines (one per CPU if workers is 0) and written in workload order.
+func WriteWorkloadIntervalVolatilityToFile(data *Data, outputFile string, interval time.Duration, workers int) error {
+    file, err := os.Create(outputFile)
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+
+    writer, err := NewIntervalChangeWriter(file, interval)
+    if err != nil {
+        return err
+    }
+
+    intervalChanges := make([][]TimedValue, len(data.Workloads))
+    errs := make([]error, len(data.Workloads))
+    parallelFor(len(data.Workloads), workers, func(i int) {
+        intervalChanges[i], errs[i] = calculateSeriesIntervalChanges(combinedLoad(data.Workloads[i]), interval)
+    })
+
+    for i, workload := range data.Workloads {
+        if errs[i] != nil {
+            return errs[i]
+        }
+        if err := writer.writeChanges(workload.Name, intervalChanges[i]); err != nil {
+            return err
+        }
+    }
+
+    return writer.Flush()
+}
+
+// IntervalChangeWriter writes the change in workloads' combined load between consecutive intervals
+// as CSV rows of Timestamp, Workload and Change, one workload at a time.
+type IntervalChangeWriter struct {
+    writer   *csv.Writer
+    interval time.Duration
+}
+
+// NewIntervalChangeWriter writes the CSV header to w and returns a writer for the rows.
+func NewIntervalChangeWriter(w io.Writer, interval time.Duration) (*IntervalChangeWriter, error) {
+    writer := csv.NewWriter(w)
+    if err := writer.Write([]string{"Timestamp", "Workload", "Change"}); err != nil {
+        return nil, err
+    }
+    return &IntervalChangeWriter{writer: writer, interval: interval}, nil
+}
+
+// write writes the interval changes of one workload's chronologically ordered combined load.
+func (c *IntervalChangeWriter) write(name string, combined []TimedValue) error {
+    intervalChanges, err := calculateSeriesIntervalChanges(combined, c.interval)
+    if err != nil {
+        return err
+    }
+    return c.writeChanges(name, intervalChanges)
+}
+
+// writeChanges writes one row per interval change of the named workload.
+func (c *IntervalChangeWriter) writeChanges(name string, intervalChanges []TimedValue) error {
+    for _, intervalChange := range intervalChanges {
+        record := []string{
+            intervalChange.Timestamp.Format(time.RFC3339),
+            name,
+            fmt.Sprintf("%.2f", intervalChange.Value),
+        }
+        if err := c.writer.Write(record); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// Flush writes any buffered rows to the underlying writer.
+func (c *IntervalChangeWriter) Flush() error {
+    c.writer.Flush()
+    return c.writer.Error()
+}
+
+// WriteCostBreakdownToFile writes how much of each workload's cost came from each dimension,
+// volume tier and time window.
+func WriteCostBreakdownToFile(data *Data, outputFile string) error {
+    file, err := os.Create(outputFile)
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+
+    writer := csv.NewWriter(file)
+    defer writer.Flush()
+
+    if err := writer.Write([]string{"Workload", "Dimension", "Tier", "Window", "Cost"}); err != nil {
+        return err
+    }
+
+    for _, workload := range data.Workloads {
+        for _, bucket := range SortedCostBuckets(workload.CostBreakdown) {
+            record := []string{
+                workload.Name,
+                bucket.Dimension,
+                bucket.Tier,
+                bucket.Window,
+                fmt.Sprintf("%.2f", workload.CostBreakdown[bucket]),
+            }
+            if err := writer.Write(record); err != nil {
+                return err
+            }
+        }
+    }
+
+    return nil
+}
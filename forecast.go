@@ -0,0 +1,395 @@
+package laplace
+
+import (
+    "encoding/csv"
+    "fmt"
+    "math"
+    "os"
+    "sort"
+    "time"
+)
+
+// Forecasting methods supported by ForecastSeries.
+const (
+    HoltWinters   = "holt-winters"
+    SeasonalNaive = "seasonal-naive"
+)
+
+// ForecastOptions configures ForecastSeries. Horizon is how far past the last value to project,
+// Season the length of the repeating pattern, and Confidence the coverage of the bands around the
+// forecast. Alpha, Beta and Gamma are the Holt-Winters smoothing factors of the level, trend and
+// seasonal components.
+type ForecastOptions struct {
+    Method     string
+    Horizon    time.Duration
+    Season     time.Duration
+    Confidence float64
+    Alpha      float64
+    Beta       float64
+    Gamma      float64
+}
+
+// DefaultForecastOptions returns a Holt-Winters forecast one day ahead with daily seasonality and
+// 95% confidence bands.
+func DefaultForecastOptions() ForecastOptions {
+    return ForecastOptions{
+        Method:     HoltWinters,
+        Horizon:    24 * time.Hour,
+        Season:     24 * time.Hour,
+        Confidence: 0.95,
+        Alpha:      0.3,
+        Beta:       0.05,
+        Gamma:      0.2,
+    }
+}
+
+// Forecast is the projection of one load series. Method is the method actually used, which falls
+// back to a non-seasonal variant when the series covers fewer seasons than the method needs:
+// Holt's linear trend for Holt-Winters and the last value for seasonal-naive.
+type Forecast struct {
+    Method string
+    Points []ForecastPoint
+}
+
+// ForecastPoint is the forecast at one future timestamp with its confidence band.
+type ForecastPoint struct {
+    Timestamp time.Time
+    Value     float64
+    Lower     float64
+    Upper     float64
+}
+
+// Non-seasonal fallbacks reported in Forecast.Method.
+const (
+    holtLinear = "holt-linear"
+    naive      = "naive"
+)
+
+// validate checks the options.
+func (o ForecastOptions) validate() error {
+    if o.Method != HoltWinters && o.Method != SeasonalNaive {
+        return fmt.Errorf("unknown forecast method %q: want %s or %s", o.Method, HoltWinters, SeasonalNaive)
+    }
+    if o.Horizon <= 0 {
+        return fmt.Errorf("forecast horizon must be positive, got %v", o.Horizon)
+    }
+    if o.Season <= 0 {
+        return fmt.Errorf("forecast season must be positive, got %v", o.Season)
+    }
+    if o.Confidence <= 0 || o.Confidence >= 1 {
+        return fmt.Errorf("forecast confidence must be between 0 and 1, got %v", o.Confidence)
+    }
+    for _, factor := range []float64{o.Alpha, o.Beta, o.Gamma} {
+        if factor < 0 || factor > 1 {
+            return fmt.Errorf("smoothing factors must be between 0 and 1, got %v", factor)
+        }
+    }
+    return nil
+}
+
+// ForecastSeries projects a load series forward by opts.Horizon. The series is treated as evenly
+// spaced at its median step, which also spaces the forecast; a season spans Season divided by
+// that step. The bands are the forecast plus and minus the normal quantile of opts.Confidence times
+// the standard error of the h-step forecast, estimated from the one-step errors on the series.
+func ForecastSeries(series []TimedValue, opts ForecastOptions) (Forecast, error) {
+    if err := opts.validate(); err != nil {
+        return Forecast{}, err
+    }
+    if len(series) == 0 {
+        return Forecast{}, fmt.Errorf("series is empty")
+    }
+
+    sorted := append([]TimedValue(nil), series...)
+    sort.SliceStable(sorted, func(i, j int) bool {
+        return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+    })
+    step := medianStep(sorted)
+    if step <= 0 {
+        step = opts.Season
+    }
+    values := make([]float64, len(sorted))
+    for i, timedValue := range sorted {
+        values[i] = timedValue.Value
+    }
+
+    horizon := int(math.Ceil(float64(opts.Horizon) / float64(step)))
+    season := int(math.Round(float64(opts.Season) / float64(step)))
+
+    var method string
+    var projected, stdErrs []float64
+    switch {
+    case opts.Method == HoltWinters && season >= 2 && len(values) >= 2*season:
+        method = HoltWinters
+        projected, stdErrs = holtWinters(values, season, horizon, opts)
+    case opts.Method == HoltWinters:
+        method = holtLinear
+        projected, stdErrs = holtWinters(values, 1, horizon, opts)
+    case season >= 1 && len(values) > season:
+        method = SeasonalNaive
+        projected, stdErrs = seasonalNaive(values, season, horizon)
+    default:
+        method = naive
+        projected, stdErrs = seasonalNaive(values, 1, horizon)
+    }
+
+    z := math.Sqrt2 * math.Erfinv(opts.Confidence)
+    last := sorted[len(sorted)-1].Timestamp
+    forecast := Forecast{Method: method, Points: make([]ForecastPoint, horizon)}
+    for h := range projected {
+        forecast.Points[h] = ForecastPoint{
+            Timestamp: last.Add(time.Duration(h+1) * step),
+            Value:     projected[h],
+            Lower:     projected[h] - z*stdErrs[h],
+            Upper:     projected[h] + z*stdErrs[h],
+        }
+    }
+    return forecast, nil
+}
+
+// medianStep returns the median time between consecutive distinct timestamps.
+func medianStep(sorted []TimedValue) time.Duration {
+    var steps []time.Duration
+    for i := 1; i < len(sorted); i++ {
+        if step := sorted[i].Timestamp.Sub(sorted[i-1].Timestamp); step > 0 {
+            steps = append(steps, step)
+        }
+    }
+    if len(steps) == 0 {
+        return 0
+    }
+    sort.Slice(steps, func(i, j int) bool { return steps[i] < steps[j] })
+    return steps[len(steps)/2]
+}
+
+// holtWinters forecasts values with additive Holt-Winters over seasons of the given length, or
+// with Holt's linear trend when season is 1. It returns the forecast and its standard error for
+// each of the next horizon steps.
+func holtWinters(values []float64, season, horizon int, opts ForecastOptions) ([]float64, []float64) {
+    alpha, beta, gamma := opts.Alpha, opts.Beta, opts.Gamma
+    seasonal := make([]float64, season)
+    var level, trend float64
+    start := 1
+
+    if season == 1 {
+        // Holt's linear trend: no seasonal component.
+        gamma = 0
+        level = values[0]
+        if len(values) > 1 {
+            trend = values[1] - values[0]
+        }
+    } else {
+        // Start from the mean of the first season, the change between the first two seasons and
+        // the first season's deviations from its mean.
+        first, second := mean(values[:season]), mean(values[season:2*season])
+        level = first
+        trend = (second - first) / float64(season)
+        for i := range seasonal {
+            seasonal[i] = values[i] - first
+        }
+        start = season
+    }
+
+    var squaredErrors float64
+    var errorCount int
+    for t := start; t < len(values); t++ {
+        s := seasonal[t%season]
+        err := values[t] - (level + trend + s)
+        squaredErrors += err * err
+        errorCount++
+
+        previousLevel := level
+        level = alpha*(values[t]-s) + (1-alpha)*(level+trend)
+        trend = beta*(level-previousLevel) + (1-beta)*trend
+        seasonal[t%season] = gamma*(values[t]-level) + (1-gamma)*s
+    }
+    var sigma float64
+    if errorCount > 0 {
+        sigma = math.Sqrt(squaredErrors / float64(errorCount))
+    }
+
+    last := len(values) - 1
+    projected := make([]float64, horizon)
+    stdErrs := make([]float64, horizon)
+    for h := 1; h <= horizon; h++ {
+        s := 0.0
+        if season > 1 {
+            s = seasonal[(last+h)%season]
+        }
+        projected[h-1] = level + float64(h)*trend + s
+
+        // Forecast variance of additive Holt-Winters (Hyndman et al., class 1 state space models).
+        fh := float64(h)
+        variance := 1 + (fh-1)*(alpha*alpha+alpha*beta*fh+beta*beta*fh*(2*fh-1)/6)
+        if season > 1 {
+            k := float64((h - 1) / season)
+            variance += k * gamma * (2*alpha + gamma + beta*float64(season)*(k+1))
+        }
+        stdErrs[h-1] = sigma * math.Sqrt(variance)
+    }
+    return projected, stdErrs
+}
+
+// seasonalNaive forecasts each step as the value one season earlier, or as the last value when
+// season is 1. It returns the forecast and its standard error for each of the next horizon steps.
+func seasonalNaive(values []float64, season, horizon int) ([]float64, []float64) {
+    var squaredErrors float64
+    var errorCount int
+    for t := season; t < len(values); t++ {
+        err := values[t] - values[t-season]
+        squaredErrors += err * err
+        errorCount++
+    }
+    var sigma float64
+    if errorCount > 0 {
+        sigma = math.Sqrt(squaredErrors / float64(errorCount))
+    }
+
+    last := len(values) - 1
+    projected := make([]float64, horizon)
+    stdErrs := make([]float64, horizon)
+    for h := 1; h <= horizon; h++ {
+        k := (h - 1) / season
+        projected[h-1] = values[last+h-season*(k+1)]
+        stdErrs[h-1] = sigma * math.Sqrt(float64(k+1))
+    }
+    return projected, stdErrs
+}
+
+func mean(values []float64) float64 {
+    if len(values) == 0 {
+        return 0
+    }
+    var total float64
+    for _, value := range values {
+        total += value
+    }
+    return total / float64(len(values))
+}
+
+// WorkloadForecast holds the forecast of each load dimension of one workload.
+type WorkloadForecast struct {
+    Name      string
+    Forecasts map[string]Forecast
+}
+
+// ForecastWorkloads forecasts each load dimension of every workload on up to workers goroutines
+// (one per CPU if workers is 0), in workload order. Empty series are left out.
+func ForecastWorkloads(workloads []Workload, opts ForecastOptions, workers int) ([]WorkloadForecast, error) {
+    if err := opts.validate(); err != nil {
+        return nil, err
+    }
+    forecasts := make([]WorkloadForecast, len(workloads))
+    parallelFor(len(workloads), workers, func(i int) {
+        forecast := WorkloadForecast{Name: workloads[i].Name, Forecasts: make(map[string]Forecast)}
+        for dimension, load := range workloads[i].Loads {
+            if len(load) == 0 {
+                continue
+            }
+            // The options were validated above and the series isn't empty, so this can't fail.
+            forecast.Forecasts[dimension], _ = ForecastSeries(load, opts)
+        }
+        forecasts[i] = forecast
+    })
+    return forecasts, nil
+}
+
+// ForecastSummed forecasts each dimension of the summed series returned by AggregateWorkloads,
+// the fleet's total load.
+func ForecastSummed(summed []SummedWorkload, opts ForecastOptions) (map[string]Forecast, error) {
+    series := make(map[string][]TimedValue)
+    for _, summedWorkload := range summed {
+        for dimension, total := range summedWorkload.Totals {
+            series[dimension] = append(series[dimension], TimedValue{Timestamp: summedWorkload.Timestamp, Value: total})
+        }
+    }
+
+    forecasts := make(map[string]Forecast, len(series))
+    for dimension, load := range series {
+        forecast, err := ForecastSeries(load, opts)
+        if err != nil {
+            return nil, fmt.Errorf("forecasting %s: %w", dimension, err)
+        }
+        forecasts[dimension] = forecast
+    }
+    return forecasts, nil
+}
+
+// ForecastsToSummed lays the forecasts of several dimensions out as summed series, one for the
+// forecast and one for each edge of the band, so they can be written by ExportWorkloadToCSV in
+// the layout of the actuals.
+func ForecastsToSummed(forecasts map[string]Forecast) (values, lower, upper []SummedWorkload) {
+    type bands struct{ values, lower, upper SummedWorkload }
+    byTimestamp := make(map[int64]*bands) // by UnixNano
+    var timestamps []time.Time
+    for dimension, forecast := range forecasts {
+        for _, point := range forecast.Points {
+            b, exists := byTimestamp[point.Timestamp.UnixNano()]
+            if !exists {
+                b = &bands{
+                    values: SummedWorkload{Timestamp: point.Timestamp, Totals: make(map[string]float64)},
+                    lower:  SummedWorkload{Timestamp: point.Timestamp, Totals: make(map[string]float64)},
+                    upper:  SummedWorkload{Timestamp: point.Timestamp, Totals: make(map[string]float64)},
+                }
+                byTimestamp[point.Timestamp.UnixNano()] = b
+                timestamps = append(timestamps, point.Timestamp)
+            }
+            b.values.Totals[dimension] = point.Value
+            b.lower.Totals[dimension] = point.Lower
+            b.upper.Totals[dimension] = point.Upper
+        }
+    }
+    sort.Slice(timestamps, func(i, j int) bool { return timestamps[i].Before(timestamps[j]) })
+
+    for _, timestamp := range timestamps {
+        b := byTimestamp[timestamp.UnixNano()]
+        values = append(values, b.values)
+        lower = append(lower, b.lower)
+        upper = append(upper, b.upper)
+    }
+    return values, lower, upper
+}
+
+// WriteWorkloadForecastsToFile writes one row per workload, dimension and forecast timestamp with
+// the forecast, the edges of its band and the method used.
+func WriteWorkloadForecastsToFile(forecasts []WorkloadForecast, outputFile string) error {
+    file, err := os.Create(outputFile)
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+
+    writer := csv.NewWriter(file)
+    defer writer.Flush()
+
+    if err := writer.Write([]string{"Workload", "Dimension", "Method", "Timestamp", "Forecast", "Lower", "Upper"}); err != nil {
+        return err
+    }
+
+    for _, workloadForecast := range forecasts {
+        dimensions := make([]string, 0, len(workloadForecast.Forecasts))
+        for dimension := range workloadForecast.Forecasts {
+            dimensions = append(dimensions, dimension)
+        }
+        sort.Strings(dimensions)
+
+        for _, dimension := range dimensions {
+            forecast := workloadForecast.Forecasts[dimension]
+            for _, point := range forecast.Points {
+                record := []string{
+                    workloadForecast.Name,
+                    dimension,
+                    forecast.Method,
+                    point.Timestamp.Format(time.RFC3339),
+                    fmt.Sprintf("%f", point.Value),
+                    fmt.Sprintf("%f", point.Lower),
+                    fmt.Sprintf("%f", point.Upper),
+                }
+                if err := writer.Write(record); err != nil {
+                    return err
+                }
+            }
+        }
+    }
+
+    return nil
+}
@@ -0,0 +1,192 @@
+package laplace
+
+import (
+    "encoding/csv"
+    "fmt"
+    "os"
+    "sort"
+    "strconv"
+    "time"
+)
+
+// Gap-filling strategies supported by FillGaps.
+const (
+    // GapMissing leaves gaps empty, so a timestamp missing from a dimension contributes no load.
+    GapMissing = "missing"
+    // GapZero fills gaps with zero load.
+    GapZero = "zero"
+    // GapForwardFill fills gaps with the last value before them.
+    GapForwardFill = "ffill"
+    // GapLinear fills gaps by interpolating linearly in time between the values either side.
+    GapLinear = "linear"
+    // GapMean fills gaps with the mean of the series.
+    GapMean = "mean"
+)
+
+// GapOptions configures FillGaps. Each workload's dimensions are aligned on the union of their
+// timestamps and, when Step is positive, on every Step from the first timestamp to the last, so
+// holes shared by every dimension are filled too.
+type GapOptions struct {
+    Strategy string
+    Step     time.Duration
+}
+
+// Validate checks the options.
+func (o GapOptions) Validate() error {
+    switch o.Strategy {
+    case GapMissing, GapZero, GapForwardFill, GapLinear, GapMean:
+    default:
+        return fmt.Errorf("unknown gap strategy %q: want %s, %s, %s, %s or %s", o.Strategy, GapMissing, GapZero, GapForwardFill, GapLinear, GapMean)
+    }
+    if o.Step < 0 {
+        return fmt.Errorf("gap step can't be negative, got %v", o.Step)
+    }
+    return nil
+}
+
+// FillGaps aligns the load dimensions of each workload on its timestamps, synthesizing the values
+// missing from each dimension with the chosen strategy, and records how many values were
+// synthesized per dimension in SynthesizedPoints. Before its first value and after its last, a
+// series is filled with that value by GapForwardFill and GapLinear. Series with no values are left
+// empty. The workloads are processed on up to workers goroutines (one per CPU if workers is 0).
+func FillGaps(workloads []Workload, opts GapOptions, workers int) error {
+    if err := opts.Validate(); err != nil {
+        return err
+    }
+    parallelFor(len(workloads), workers, func(i int) {
+        workloads[i].SynthesizedPoints = fillWorkloadGaps(&workloads[i], opts)
+    })
+    return nil
+}
+
+// fillWorkloadGaps fills the gaps of one workload and returns the number of values synthesized
+// per dimension.
+func fillWorkloadGaps(workload *Workload, opts GapOptions) map[string]int {
+    synthesized := make(map[string]int, len(workload.Loads))
+    axis := workloadAxis(workload.Loads, opts.Step)
+    for _, dimension := range workload.Dimensions() {
+        filled, count := fillSeriesGaps(workload.Loads[dimension], axis, opts.Strategy)
+        workload.Loads[dimension] = filled
+        synthesized[dimension] = count
+    }
+    return synthesized
+}
+
+// workloadAxis returns the sorted union of the timestamps of every load series, adding every step
+// from the first to the last when step is positive.
+func workloadAxis(loads map[string][]TimedValue, step time.Duration) []time.Time {
+    seen := make(map[int64]bool)
+    var axis []time.Time
+    add := func(timestamp time.Time) {
+        key := timestamp.UnixNano()
+        if !seen[key] {
+            seen[key] = true
+            axis = append(axis, timestamp)
+        }
+    }
+    for _, load := range loads {
+        for _, timedValue := range load {
+            add(timedValue.Timestamp)
+        }
+    }
+    sort.Slice(axis, func(i, j int) bool {
+        return axis[i].Before(axis[j])
+    })
+    if step <= 0 || len(axis) == 0 {
+        return axis
+    }
+
+    first, last := axis[0], axis[len(axis)-1]
+    for timestamp := first.Add(step); timestamp.Before(last); timestamp = timestamp.Add(step) {
+        add(timestamp)
+    }
+    sort.Slice(axis, func(i, j int) bool {
+        return axis[i].Before(axis[j])
+    })
+    return axis
+}
+
+// fillSeriesGaps returns the series in time order with a synthesized value at every timestamp of
+// the axis it has no value at, and the number of values synthesized.
+func fillSeriesGaps(series []TimedValue, axis []time.Time, strategy string) ([]TimedValue, int) {
+    if strategy == GapMissing || len(series) == 0 {
+        return series, 0
+    }
+    sorted := append([]TimedValue(nil), series...)
+    sort.SliceStable(sorted, func(i, j int) bool {
+        return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+    })
+    average := mean(timedValues(sorted))
+
+    filled := make([]TimedValue, 0, len(axis))
+    synthesized := 0
+    next := 0 // index of the first value of the series not yet copied
+    for _, timestamp := range axis {
+        if next < len(sorted) && sorted[next].Timestamp.Equal(timestamp) {
+            for next < len(sorted) && sorted[next].Timestamp.Equal(timestamp) {
+                filled = append(filled, sorted[next])
+                next++
+            }
+            continue
+        }
+
+        var value float64
+        switch strategy {
+        case GapMean:
+            value = average
+        case GapForwardFill:
+            value = sorted[max(next-1, 0)].Value
+        case GapLinear:
+            value = interpolate(sorted, next, timestamp)
+        }
+        filled = append(filled, TimedValue{Timestamp: timestamp, Value: value})
+        synthesized++
+    }
+    return filled, synthesized
+}
+
+// interpolate returns the value at timestamp on the line between the values of the sorted series
+// either side of index next, holding the first or last value outside the series.
+func interpolate(sorted []TimedValue, next int, timestamp time.Time) float64 {
+    if next == 0 {
+        return sorted[0].Value
+    }
+    if next == len(sorted) {
+        return sorted[len(sorted)-1].Value
+    }
+    before, after := sorted[next-1], sorted[next]
+    span := after.Timestamp.Sub(before.Timestamp)
+    if span <= 0 {
+        return before.Value
+    }
+    fraction := float64(timestamp.Sub(before.Timestamp)) / float64(span)
+    return before.Value + (after.Value-before.Value)*fraction
+}
+
+// WriteSynthesizedPointsToFile writes the number of values FillGaps synthesized in each dimension
+// of each workload.
+func WriteSynthesizedPointsToFile(data *Data, outputFile string) error {
+    file, err := os.Create(outputFile)
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+
+    writer := csv.NewWriter(file)
+    defer writer.Flush()
+
+    if err := writer.Write([]string{"Workload", "Dimension", "Synthesized"}); err != nil {
+        return err
+    }
+
+    for _, workload := range data.Workloads {
+        for _, dimension := range workload.Dimensions() {
+            record := []string{workload.Name, dimension, strconv.Itoa(workload.SynthesizedPoints[dimension])}
+            if err := writer.Write(record); err != nil {
+                return err
+            }
+        }
+    }
+
+    return nil
+}
@@ -0,0 +1,113 @@
+package laplace
+
+import (
+    "fmt"
+    "math/rand"
+    "strings"
+    "time"
+)
+
+const (
+    highVolatilityPercentage = 30 // 30% of the workloads will have high volatility
+)
+
+// DefaultDimensions are the load dimensions generated when the user doesn't name any.
+var DefaultDimensions = []string{"load1", "load2", "load3"}
+
+// NewWorkload creates a workload with a random series of numIntegers values for each dimension.
+func NewWorkload(name string, dimensions []string, numIntegers int, isVolatile bool) *Workload {
+    var maxRange float64 = 100
+
+    if isVolatile {
+        maxRange = 200 // Higher range for volatile workloads
+    }
+
+    // Adjust ValueGenerated with potential for higher volatility
+    valueGenerated := rand.Intn(100) + 1
+    if isVolatile {
+        valueGenerated += rand.Intn(200) // Adding more variability
+    }
+
+    loads := make(map[string][]TimedValue, len(dimensions))
+    for _, dimension := range dimensions {
+        loads[dimension] = generateTimedRandomSlice(numIntegers, maxRange, isVolatile)
+    }
+
+    return &Workload{
+        Name:           name,
+        Loads:          loads,
+        ValueGenerated: float64(valueGenerated),
+    }
+}
+
+// ParseDimensions splits a comma-separated list of dimension names, falling back to the defaults.
+func ParseDimensions(input string) []string {
+    var dimensions []string
+    for _, dimension := range strings.Split(input, ",") {
+        dimension = strings.TrimSpace(dimension)
+        if dimension != "" {
+            dimensions = append(dimensions, dimension)
+        }
+    }
+    if len(dimensions) == 0 {
+        return DefaultDimensions
+    }
+    return dimensions
+}
+
+func generateTimedRandomSlice(n int, maxRange float64, isVolatile bool) []TimedValue {
+    slice := make([]TimedValue, n)
+    startTime := time.Date(time.Now().Year(), time.Now().Month(), time.Now().Day(), 0, 0, 0, 0, time.Local)
+
+    for i := 0; i < n; i++ {
+        // Generate a random or volatile value
+        generatedValue := rand.Float64() * maxRange
+        if isVolatile {
+            // Apply your volatility logic here
+            generatedValue += rand.NormFloat64() * maxRange / 2
+        }
+
+        // Store the timestamp and value
+        slice[i] = TimedValue{
+            Timestamp: startTime.Add(time.Duration(i) * time.Minute),
+            Value:     generatedValue,
+        }
+    }
+    return slice
+}
+
+// GenerateWorkloads creates numWorkloads named Workload1..N, a random 30% of which are
+// less volatile and the rest volatile.
+func GenerateWorkloads(numWorkloads, numIntegers int, dimensions []string) []Workload {
+    // Initialize workloads slice
+    workloads := make([]Workload, numWorkloads)
+
+    // Determine counts for less volatile and volatile workloads
+    numLessVolatile := int(float64(numWorkloads) * 30 / 100)
+    numVolatile := numWorkloads - numLessVolatile
+
+    // Create a slice of indices and shuffle it
+    indices := make([]int, numWorkloads)
+    for i := range indices {
+        indices[i] = i
+    }
+    rand.Shuffle(len(indices), func(i, j int) {
+        indices[i], indices[j] = indices[j], indices[i]
+    })
+
+    // Assign volatility based on shuffled indices
+    isVolatileMap := make(map[int]bool)
+    for _, idx := range indices[:numVolatile] {
+        isVolatileMap[idx] = true
+    }
+
+    // Generate workloads with specified volatility
+    for i := 0; i < numWorkloads; i++ {
+        name := fmt.Sprintf("Workload%d", i+1)
+        isVolatile := isVolatileMap[i]
+        workload := NewWorkload(name, dimensions, numIntegers, isVolatile)
+        workloads[i] = *workload
+    }
+
+    return workloads
+}
@@ -0,0 +1,221 @@
+package laplace
+
+import (
+    "sort"
+    "time"
+)
+
+// SeriesIndex is a time-indexed view of a set of workloads, built once and reused for aggregation,
+// peak detection and peak attribution. Axis holds every timestamp the workloads report in order,
+// and each load value is resolved to its position on the axis up front, so later lookups neither
+// rescan the series nor rebuild maps keyed by time.
+type SeriesIndex struct {
+    Axis       []time.Time
+    Dimensions []string
+
+    workloads []Workload
+    // positions[w][dimension] holds the axis position of each value of workloads[w].Loads[dimension].
+    positions []map[string]seriesPositions
+    // totals[d][p] is the load of Dimensions[d] summed across every workload at Axis[p].
+    totals [][]float64
+}
+
+// seriesPositions are the axis positions of one load series, in the order of its values.
+type seriesPositions struct {
+    offsets []int32
+    sorted  bool
+}
+
+// NewSeriesIndex indexes the workloads on up to workers goroutines (one per CPU if workers is 0).
+// The workloads must not be modified while the index is in use.
+func NewSeriesIndex(workloads []Workload, workers int) *SeriesIndex {
+    index := &SeriesIndex{
+        Dimensions: CollectDimensions(workloads),
+        workloads:  workloads,
+    }
+
+    // Collect the timestamps of each chunk of workloads in parallel and merge them into the axis.
+    // Timestamps are keyed by instant, so the same instant written in different time zones shares
+    // one position; the axis keeps the first form met, in workload and dimension order.
+    chunks := chunkWorkloads(workloads)
+    seen := make([]map[int64]time.Time, len(chunks))
+    parallelFor(len(chunks), workers, func(i int) {
+        seen[i] = make(map[int64]time.Time)
+        for _, workload := range chunks[i] {
+            for _, dimension := range workload.Dimensions() {
+                for _, timedValue := range workload.Loads[dimension] {
+                    key := timedValue.Timestamp.UnixNano()
+                    if _, exists := seen[i][key]; !exists {
+                        seen[i][key] = timedValue.Timestamp
+                    }
+                }
+            }
+        }
+    })
+    positions := make(map[int64]int32)
+    for _, timestamps := range seen {
+        for key, timestamp := range timestamps {
+            if _, exists := positions[key]; !exists {
+                positions[key] = 0
+                index.Axis = append(index.Axis, timestamp)
+            }
+        }
+    }
+    sort.Slice(index.Axis, func(i, j int) bool {
+        return index.Axis[i].Before(index.Axis[j])
+    })
+    for i, timestamp := range index.Axis {
+        positions[timestamp.UnixNano()] = int32(i)
+    }
+
+    // Resolve every value to its axis position.
+    index.positions = make([]map[string]seriesPositions, len(workloads))
+    parallelFor(len(workloads), workers, func(w int) {
+        series := make(map[string]seriesPositions, len(workloads[w].Loads))
+        for dimension, load := range workloads[w].Loads {
+            offsets := make([]int32, len(load))
+            sorted := true
+            for i, timedValue := range load {
+                offsets[i] = positions[timedValue.Timestamp.UnixNano()]
+                if i > 0 && offsets[i] < offsets[i-1] {
+                    sorted = false
+                }
+            }
+            series[dimension] = seriesPositions{offsets: offsets, sorted: sorted}
+        }
+        index.positions[w] = series
+    })
+
+    // Sum each dimension along the axis. Dimensions are summed in parallel, but the workloads of a
+    // dimension are always added in the same order, so the totals don't depend on the worker count.
+    index.totals = make([][]float64, len(index.Dimensions))
+    parallelFor(len(index.Dimensions), workers, func(d int) {
+        dimension := index.Dimensions[d]
+        totals := make([]float64, len(index.Axis))
+        for w, workload := range workloads {
+            offsets := index.positions[w][dimension].offsets
+            for i, timedValue := range workload.Loads[dimension] {
+                totals[offsets[i]] += timedValue.Value
+            }
+        }
+        index.totals[d] = totals
+    })
+
+    return index
+}
+
+// Position returns the position of the timestamp on the axis, and false if no workload reports it.
+func (x *SeriesIndex) Position(timestamp time.Time) (int, bool) {
+    i := sort.Search(len(x.Axis), func(i int) bool {
+        return !x.Axis[i].Before(timestamp)
+    })
+    if i < len(x.Axis) && x.Axis[i].Equal(timestamp) {
+        return i, true
+    }
+    return 0, false
+}
+
+// row lays the load of workloads[w] in dimension out on the axis, summing every dimension for
+// TotalDimension. present marks the positions the workload reports a value at.
+func (x *SeriesIndex) row(w int, dimension string) (values []float64, present []bool) {
+    values = make([]float64, len(x.Axis))
+    present = make([]bool, len(x.Axis))
+    workload := x.workloads[w]
+    for _, name := range workload.Dimensions() {
+        if dimension != TotalDimension && name != dimension {
+            continue
+        }
+        offsets := x.positions[w][name].offsets
+        for i, timedValue := range workload.Loads[name] {
+            values[offsets[i]] += timedValue.Value
+            present[offsets[i]] = true
+        }
+    }
+    return values, present
+}
+
+// Summed returns the workloads' load summed per dimension at each timestamp of the axis.
+func (x *SeriesIndex) Summed() []SummedWorkload {
+    summed := make([]SummedWorkload, len(x.Axis))
+    for p, timestamp := range x.Axis {
+        totals := make(map[string]float64, len(x.Dimensions))
+        for d, dimension := range x.Dimensions {
+            totals[dimension] = x.totals[d][p]
+        }
+        summed[p] = SummedWorkload{Timestamp: timestamp, Totals: totals}
+    }
+    return summed
+}
+
+// TotalAt returns the load summed across every workload and dimension at an axis position.
+func (x *SeriesIndex) TotalAt(position int) float64 {
+    var total float64
+    for d := range x.Dimensions {
+        total += x.totals[d][position]
+    }
+    return total
+}
+
+// Peak returns the timestamp at which the load summed across every dimension is highest. The
+// earliest timestamp wins a tie.
+func (x *SeriesIndex) Peak() PeakUsage {
+    peakUsage := PeakUsage{Dimension: TotalDimension}
+    for p, timestamp := range x.Axis {
+        if total := x.TotalAt(p); total > peakUsage.TotalUsage {
+            peakUsage = PeakUsage{Dimension: TotalDimension, Timestamp: timestamp, TotalUsage: total}
+        }
+    }
+    return peakUsage
+}
+
+// LoadAt returns the load of the w-th workload summed across its dimensions at an axis position.
+// Like GetLoadAtTimestamp, only the first value of a series at that position is counted.
+func (x *SeriesIndex) LoadAt(w, position int) float64 {
+    var total float64
+    for _, dimension := range x.workloads[w].Dimensions() {
+        total += x.DimensionLoadAt(w, dimension, position)
+    }
+    return total
+}
+
+// DimensionLoadAt returns the value of one dimension of the w-th workload at an axis position,
+// or 0 if the series has no value there. Sorted series are binary searched.
+func (x *SeriesIndex) DimensionLoadAt(w int, dimension string, position int) float64 {
+    series := x.positions[w][dimension]
+    load := x.workloads[w].Loads[dimension]
+    target := int32(position)
+    if series.sorted {
+        i := sort.Search(len(series.offsets), func(i int) bool {
+            return series.offsets[i] >= target
+        })
+        if i < len(series.offsets) && series.offsets[i] == target {
+            return load[i].Value
+        }
+        return 0
+    }
+    for i, offset := range series.offsets {
+        if offset == target {
+            return load[i].Value
+        }
+    }
+    return 0
+}
+
+// PeakContributions returns each workload's load in the peak's dimension at the peak's timestamp,
+// summed across its dimensions for a TotalDimension peak, in workload order.
+func (x *SeriesIndex) PeakContributions(peak PeakUsage) []WorkloadContribution {
+    position, found := x.Position(peak.Timestamp)
+    contributions := make([]WorkloadContribution, len(x.workloads))
+    for w, workload := range x.workloads {
+        contributions[w] = WorkloadContribution{Name: workload.Name}
+        if !found {
+            continue
+        }
+        if peak.Dimension == TotalDimension {
+            contributions[w].LoadAtPeak = x.LoadAt(w, position)
+        } else {
+            contributions[w].LoadAtPeak = x.DimensionLoadAt(w, peak.Dimension, position)
+        }
+    }
+    return contributions
+}
@@ -0,0 +1,62 @@
+package laplace
+
+import (
+    "runtime"
+    "sync"
+)
+
+// mergeChunkSize is how many workloads each partial result covers before the partial results are
+// merged. It is fixed, rather than derived from the worker count, so floating-point sums are added
+// in the same order and the output is identical however many workers run.
+const mergeChunkSize = 64
+
+// Workers returns the number of goroutines to use for a requested worker count, defaulting to one
+// per CPU when workers is zero or negative.
+func Workers(workers int) int {
+    if workers <= 0 {
+        return runtime.GOMAXPROCS(0)
+    }
+    return workers
+}
+
+// parallelFor calls fn with every index in [0, n) on up to workers goroutines and waits for them to
+// return. fn must only write to state owned by its index.
+func parallelFor(n, workers int, fn func(i int)) {
+    workers = Workers(workers)
+    if workers > n {
+        workers = n
+    }
+    if workers <= 1 {
+        for i := 0; i < n; i++ {
+            fn(i)
+        }
+        return
+    }
+
+    indexes := make(chan int)
+    var wg sync.WaitGroup
+    for w := 0; w < workers; w++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for i := range indexes {
+                fn(i)
+            }
+        }()
+    }
+    for i := 0; i < n; i++ {
+        indexes <- i
+    }
+    close(indexes)
+    wg.Wait()
+}
+
+// chunkWorkloads splits workloads into consecutive chunks of mergeChunkSize.
+func chunkWorkloads(workloads []Workload) [][]Workload {
+    var chunks [][]Workload
+    for start := 0; start < len(workloads); start += mergeChunkSize {
+        end := min(start+mergeChunkSize, len(workloads))
+        chunks = append(chunks, workloads[start:end])
+    }
+    return chunks
+}
@@ -0,0 +1,149 @@
+package laplace
+
+import (
+    "encoding/csv"
+    "fmt"
+    "os"
+    "sort"
+    "strconv"
+    "time"
+)
+
+// PeakAttribution is one of the highest points of a summed load series with the workloads that
+// contributed to it.
+type PeakAttribution struct {
+    Peak PeakUsage
+    // Rank is the peak's place among the peaks of its dimension, starting at 1.
+    Rank int
+    // Contributors holds every workload's load at the peak, highest first.
+    Contributors []WorkloadContribution
+}
+
+// FindTopPeaks returns up to n timestamps of the summed series at which the load of dimension is
+// highest, highest first, or of every dimension together when dimension is TotalDimension. A
+// timestamp less than minGap away from a higher peak is skipped, so one sustained burst isn't
+// reported as several peaks. Only timestamps with a positive load are considered.
+func FindTopPeaks(summed []SummedWorkload, dimension string, n int, minGap time.Duration) []PeakUsage {
+    candidates := make([]PeakUsage, 0, len(summed))
+    for _, summedWorkload := range summed {
+        usage := summedUsage(summedWorkload, dimension)
+        if usage > 0 {
+            candidates = append(candidates, PeakUsage{Dimension: dimension, Timestamp: summedWorkload.Timestamp, TotalUsage: usage})
+        }
+    }
+    // Highest usage first; the earliest timestamp wins a tie.
+    sort.SliceStable(candidates, func(i, j int) bool {
+        if candidates[i].TotalUsage != candidates[j].TotalUsage {
+            return candidates[i].TotalUsage > candidates[j].TotalUsage
+        }
+        return candidates[i].Timestamp.Before(candidates[j].Timestamp)
+    })
+
+    var peaks []PeakUsage
+    for _, candidate := range candidates {
+        if len(peaks) >= n {
+            break
+        }
+        if !tooClose(candidate.Timestamp, peaks, minGap) {
+            peaks = append(peaks, candidate)
+        }
+    }
+    return peaks
+}
+
+// summedUsage returns the load of dimension at one timestamp of the summed series, adding the
+// dimensions in sorted order for TotalDimension so the total doesn't depend on map iteration.
+func summedUsage(summedWorkload SummedWorkload, dimension string) float64 {
+    if dimension != TotalDimension {
+        return summedWorkload.Totals[dimension]
+    }
+    dimensions := make([]string, 0, len(summedWorkload.Totals))
+    for dimension := range summedWorkload.Totals {
+        dimensions = append(dimensions, dimension)
+    }
+    sort.Strings(dimensions)
+
+    var total float64
+    for _, dimension := range dimensions {
+        total += summedWorkload.Totals[dimension]
+    }
+    return total
+}
+
+// tooClose reports whether the timestamp is less than minGap away from any of the peaks.
+func tooClose(timestamp time.Time, peaks []PeakUsage, minGap time.Duration) bool {
+    for _, peak := range peaks {
+        gap := timestamp.Sub(peak.Timestamp)
+        if gap < 0 {
+            gap = -gap
+        }
+        if gap < minGap {
+            return true
+        }
+    }
+    return false
+}
+
+// AttributePeaks pairs each peak with the workload contributions at it, as returned by
+// SeriesIndex.PeakContributions or StreamContributionsAt, sorting the contributors highest first
+// and ranking the peaks within their dimension in the order given.
+func AttributePeaks(peaks []PeakUsage, contributions [][]WorkloadContribution) []PeakAttribution {
+    attributions := make([]PeakAttribution, len(peaks))
+    ranks := make(map[string]int)
+    for i, peak := range peaks {
+        ranks[peak.Dimension]++
+        attributions[i] = PeakAttribution{Peak: peak, Rank: ranks[peak.Dimension], Contributors: sortContributions(contributions[i])}
+    }
+    return attributions
+}
+
+// sortContributions returns a copy of the contributions sorted by load, highest first. Workloads
+// with equal loads keep their order.
+func sortContributions(contributions []WorkloadContribution) []WorkloadContribution {
+    sorted := append([]WorkloadContribution(nil), contributions...)
+    sort.SliceStable(sorted, func(i, j int) bool {
+        return sorted[i].LoadAtPeak > sorted[j].LoadAtPeak
+    })
+    return sorted
+}
+
+// WritePeaksToFile writes one row per workload contributing to each peak: the peak's dimension,
+// rank, timestamp and usage, then the workload, its load at the peak and its share of the peak.
+// Workloads with no load at a peak are left out.
+func WritePeaksToFile(attributions []PeakAttribution, outputFile string) error {
+    file, err := os.Create(outputFile)
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+
+    writer := csv.NewWriter(file)
+    defer writer.Flush()
+
+    if err := writer.Write([]string{"Dimension", "Rank", "Timestamp", "Usage", "Workload", "LoadAtPeak", "Share"}); err != nil {
+        return err
+    }
+
+    for _, attribution := range attributions {
+        peak := attribution.Peak
+        for _, contributor := range attribution.Contributors {
+            if contributor.LoadAtPeak == 0 {
+                continue
+            }
+            record := []string{
+                peak.Dimension,
+                strconv.Itoa(attribution.Rank),
+                peak.Timestamp.Format(time.RFC3339),
+                fmt.Sprintf("%.2f", peak.TotalUsage),
+                contributor.Name,
+                fmt.Sprintf("%.2f", contributor.LoadAtPeak),
+                fmt.Sprintf("%.2f", contributor.LoadAtPeak/peak.TotalUsage*100),
+            }
+            if err := writer.Write(record); err != nil {
+                return err
+            }
+        }
+    }
+
+    return nil
+}
@@ -0,0 +1,137 @@
+package laplace
+
+import (
+    "encoding/csv"
+    "fmt"
+    "math"
+    "os"
+    "sort"
+)
+
+// Percentiles holds the 50th, 95th and 99th percentile of a load series, the figures capacity
+// planning and burst billing are based on.
+type Percentiles struct {
+    P50 float64
+    P95 float64
+    P99 float64
+}
+
+// calculatePercentiles returns the percentiles of values using the nearest-rank method, as 95th
+// percentile billing does: the p-th percentile is the smallest value at least p% of the values are
+// less than or equal to. It returns zero percentiles for no values.
+func calculatePercentiles(values []float64) Percentiles {
+    if len(values) == 0 {
+        return Percentiles{}
+    }
+    sorted := append([]float64(nil), values...)
+    sort.Float64s(sorted)
+    return Percentiles{
+        P50: nearestRank(sorted, 50),
+        P95: nearestRank(sorted, 95),
+        P99: nearestRank(sorted, 99),
+    }
+}
+
+// nearestRank returns the p-th percentile of sorted values.
+func nearestRank(sorted []float64, p float64) float64 {
+    rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+    if rank < 1 {
+        rank = 1
+    }
+    return sorted[rank-1]
+}
+
+// calculateLoadPercentiles returns the percentiles of each of the workload's load dimensions.
+func calculateLoadPercentiles(workload Workload) map[string]Percentiles {
+    percentiles := make(map[string]Percentiles, len(workload.Loads))
+    for dimension, load := range workload.Loads {
+        values := make([]float64, len(load))
+        for i, timedValue := range load {
+            values[i] = timedValue.Value
+        }
+        percentiles[dimension] = calculatePercentiles(values)
+    }
+    return percentiles
+}
+
+// SummedPercentiles returns the fleet-wide percentiles of the summed series returned by
+// AggregateWorkloads, for each dimension and for TotalDimension, the load summed across every
+// dimension. The P95 of TotalDimension is the 95th-percentile peak of the fleet.
+func SummedPercentiles(summed []SummedWorkload) map[string]Percentiles {
+    series := make(map[string][]float64)
+    for _, summedWorkload := range summed {
+        for dimension, total := range summedWorkload.Totals {
+            series[dimension] = append(series[dimension], total)
+        }
+        series[TotalDimension] = append(series[TotalDimension], summedUsage(summedWorkload, TotalDimension))
+    }
+
+    percentiles := make(map[string]Percentiles, len(series))
+    for dimension, values := range series {
+        percentiles[dimension] = calculatePercentiles(values)
+    }
+    return percentiles
+}
+
+// PrintSummedPercentiles prints the fleet-wide percentiles returned by SummedPercentiles.
+func PrintSummedPercentiles(percentiles map[string]Percentiles) {
+    fmt.Println("\nFleet Load Percentiles:")
+    for _, dimension := range sortedPercentileDimensions(percentiles) {
+        p := percentiles[dimension]
+        fmt.Printf("  %s: P50 %.2f, P95 %.2f, P99 %.2f\n", dimension, p.P50, p.P95, p.P99)
+    }
+    fmt.Printf("95th Percentile Peak of Summed Load: %.2f\n", percentiles[TotalDimension].P95)
+}
+
+// WritePercentilesToFile writes the percentiles of each workload's load dimensions, calculated by
+// CalculateWorkloadStats, followed by the fleet-wide percentiles returned by SummedPercentiles.
+// The Scope column tells the two apart.
+func WritePercentilesToFile(data *Data, fleet map[string]Percentiles, outputFile string) error {
+    file, err := os.Create(outputFile)
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+
+    writer := csv.NewWriter(file)
+    defer writer.Flush()
+
+    if err := writer.Write([]string{"Scope", "Workload", "Dimension", "P50", "P95", "P99"}); err != nil {
+        return err
+    }
+
+    write := func(scope, name, dimension string, p Percentiles) error {
+        return writer.Write([]string{
+            scope,
+            name,
+            dimension,
+            fmt.Sprintf("%.2f", p.P50),
+            fmt.Sprintf("%.2f", p.P95),
+            fmt.Sprintf("%.2f", p.P99),
+        })
+    }
+    for _, workload := range data.Workloads {
+        for _, dimension := range sortedPercentileDimensions(workload.Percentiles) {
+            if err := write("workload", workload.Name, dimension, workload.Percentiles[dimension]); err != nil {
+                return err
+            }
+        }
+    }
+    for _, dimension := range sortedPercentileDimensions(fleet) {
+        if err := write("fleet", "", dimension, fleet[dimension]); err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// sortedPercentileDimensions returns the dimensions of percentiles in sorted order.
+func sortedPercentileDimensions(percentiles map[string]Percentiles) []string {
+    dimensions := make([]string, 0, len(percentiles))
+    for dimension := range percentiles {
+        dimensions = append(dimensions, dimension)
+    }
+    sort.Strings(dimensions)
+    return dimensions
+}
@@ -0,0 +1,365 @@
+package laplace
+
+import (
+    "encoding/csv"
+    "encoding/json"
+    "fmt"
+    "math"
+    "os"
+    "sort"
+    "time"
+)
+
+// Host is a bin that workloads can be placed on, with a capacity per load dimension.
+// Dimensions without a capacity are unlimited.
+type Host struct {
+    Name     string             `json:"name"`
+    Capacity map[string]float64 `json:"capacity"`
+}
+
+// PlacementWeights sets how much placement favors spreading cost evenly across hosts and keeping
+// each host's combined load steady, relative to minimizing its peak utilization.
+type PlacementWeights struct {
+    Cost       float64 `json:"cost"`
+    Volatility float64 `json:"volatility"`
+}
+
+// HostPlacement is the set of workloads assigned to a host and the resulting load on it.
+// PeakUtilization is the peak of the summed load as a fraction of capacity per dimension, and
+// Volatility the mean volatility of the summed load relative to capacity.
+type HostPlacement struct {
+    Host            string             `json:"host"`
+    Workloads       []string           `json:"workloads"`
+    PeakUtilization map[string]float64 `json:"peakUtilization"`
+    MaxUtilization  float64            `json:"maxUtilization"`
+    TotalCost       float64            `json:"totalCost"`
+    Volatility      float64            `json:"volatility"`
+}
+
+// Placement assigns workloads to hosts. Unplaced lists the workloads that fit on no host.
+type Placement struct {
+    Hosts    []HostPlacement `json:"hosts"`
+    Unplaced []string        `json:"unplaced"`
+}
+
+// hostState is a host during placement. totals[d][p] is the load of index.Dimensions[d] summed
+// across the host's workloads at index.Axis[p], and reported[d][p] whether any of them reports
+// that dimension there; both are updated as workloads are added.
+type hostState struct {
+    host      Host
+    index     *SeriesIndex
+    workloads []string
+    totals    [][]float64
+    reported  [][]bool
+    cost      float64
+    averages  []float64 // scratch space for the interval averages of scoreWith
+}
+
+// placementSeries is a workload's load as points on the index axis, by dimension index.
+type placementSeries [][]axisValue
+
+// axisValue is a load value at a position of the index axis.
+type axisValue struct {
+    position int
+    value    float64
+}
+
+// placementScore is the outcome of adding a workload to a host.
+type placementScore struct {
+    fits            bool
+    score           float64
+    peakUtilization map[string]float64
+    maxUtilization  float64
+    volatility      float64
+}
+
+// LoadHosts loads the hosts to place workloads on from a JSON file shaped as
+// {"hosts": [{"name": "host-a", "capacity": {"cpu": 400, "mem": 1024}}]}.
+func LoadHosts(filename string) ([]Host, error) {
+    file, err := os.Open(filename)
+    if err != nil {
+        return nil, err
+    }
+    defer file.Close()
+
+    var config struct {
+        Hosts []Host `json:"hosts"`
+    }
+    if err := json.NewDecoder(file).Decode(&config); err != nil {
+        return nil, fmt.Errorf("decoding hosts %s: %w", filename, err)
+    }
+    if len(config.Hosts) == 0 {
+        return nil, fmt.Errorf("hosts %s: no hosts defined", filename)
+    }
+    return config.Hosts, nil
+}
+
+// PlaceWorkloads assigns each workload to a host, largest peak first. Every workload goes to the
+// host where adding it gives the lowest score: the host's peak utilization across dimensions, plus
+// weights.Cost times the host's share of the average cost per host, plus weights.Volatility times
+// the volatility of the host's summed load relative to capacity. Because the score measures the
+// peak of the summed series, workloads that peak at different times (anti-correlated workloads)
+// are paired on the same host. Only hosts the workload fits on are considered, so a workload is left
+// unplaced when it would push every host past capacity.
+//
+// PlaceWorkloads uses the costs from CalculateWorkloadStats and measures volatility over windows
+// of the given interval.
+func PlaceWorkloads(workloads []Workload, hosts []Host, weights PlacementWeights, interval time.Duration) (Placement, error) {
+    if len(hosts) == 0 {
+        return Placement{}, fmt.Errorf("no hosts to place workloads on")
+    }
+    if interval <= 0 {
+        return Placement{}, fmt.Errorf("interval must be positive, got %v", interval)
+    }
+
+    // Lay every workload out once on a shared axis; hosts keep their summed load on the same axis.
+    index := NewSeriesIndex(workloads, 0)
+    states := make([]*hostState, len(hosts))
+    for i, host := range hosts {
+        states[i] = &hostState{
+            host:     host,
+            index:    index,
+            totals:   make([][]float64, len(index.Dimensions)),
+            reported: make([][]bool, len(index.Dimensions)),
+        }
+    }
+
+    series := make([]placementSeries, len(workloads))
+    peaks := make([]float64, len(workloads))
+    var fleetCost float64
+    for i, workload := range workloads {
+        series[i] = make(placementSeries, len(index.Dimensions))
+        for d, dimension := range index.Dimensions {
+            if _, exists := workload.Loads[dimension]; !exists {
+                continue
+            }
+            values, present := index.row(i, dimension)
+            for p, reported := range present {
+                if reported {
+                    series[i][d] = append(series[i][d], axisValue{position: p, value: values[p]})
+                }
+            }
+        }
+        values, present := index.row(i, TotalDimension)
+        for p, reported := range present {
+            if reported {
+                peaks[i] = math.Max(peaks[i], values[p])
+            }
+        }
+        fleetCost += workload.TotalCost
+    }
+    averageHostCost := fleetCost / float64(len(hosts))
+
+    // Place the workloads with the largest peak first so the big ones still find room.
+    order := make([]int, len(workloads))
+    for i := range workloads {
+        order[i] = i
+    }
+    sort.SliceStable(order, func(a, b int) bool {
+        if peaks[order[a]] != peaks[order[b]] {
+            return peaks[order[a]] > peaks[order[b]]
+        }
+        return workloads[order[a]].Name < workloads[order[b]].Name
+    })
+
+    var placement Placement
+    for _, i := range order {
+        workload := workloads[i]
+        best := -1
+        var bestScore placementScore
+        for h, state := range states {
+            score, err := state.scoreWith(workload, series[i], weights, averageHostCost, interval)
+            if err != nil {
+                return Placement{}, err
+            }
+            if score.fits && (best == -1 || score.score < bestScore.score) {
+                best, bestScore = h, score
+            }
+        }
+        if best == -1 {
+            placement.Unplaced = append(placement.Unplaced, workload.Name)
+            continue
+        }
+        states[best].add(workload, series[i])
+    }
+
+    for _, state := range states {
+        score, err := state.scoreWith(Workload{}, nil, weights, averageHostCost, interval)
+        if err != nil {
+            return Placement{}, err
+        }
+        placement.Hosts = append(placement.Hosts, HostPlacement{
+            Host:            state.host.Name,
+            Workloads:       state.workloads,
+            PeakUtilization: score.peakUtilization,
+            MaxUtilization:  score.maxUtilization,
+            TotalCost:       state.cost,
+            Volatility:      score.volatility,
+        })
+    }
+    sort.Strings(placement.Unplaced)
+    return placement, nil
+}
+
+// add assigns the workload, with the given load on the index axis, to the host.
+func (s *hostState) add(workload Workload, series placementSeries) {
+    for d, points := range series {
+        if len(points) == 0 {
+            continue
+        }
+        if s.totals[d] == nil {
+            s.totals[d] = make([]float64, len(s.index.Axis))
+            s.reported[d] = make([]bool, len(s.index.Axis))
+        }
+        for _, point := range points {
+            s.totals[d][point.position] += point.value
+            s.reported[d][point.position] = true
+        }
+    }
+    s.workloads = append(s.workloads, workload.Name)
+    s.cost += workload.TotalCost
+}
+
+// scoreWith scores the host as if the workload, with the given load on the index axis, were
+// added. Each dimension of the host's summed load is scanned once along the axis, with the
+// workload's values merged in, for its peak and the averages of its volatility windows.
+func (s *hostState) scoreWith(workload Workload, series placementSeries, weights PlacementWeights, averageHostCost float64, interval time.Duration) (placementScore, error) {
+    result := placementScore{fits: true, peakUtilization: make(map[string]float64)}
+    var scaledVolatility float64
+    var loadedDimensions int
+    for d, dimension := range s.index.Dimensions {
+        var points []axisValue
+        if series != nil {
+            points = series[d]
+        }
+        if s.totals[d] == nil && len(points) == 0 {
+            continue
+        }
+
+        // Windows follow groupByInterval, measured from the first timestamp with a value.
+        var peak, windowSum float64
+        var windowStart time.Time
+        var windowCount int
+        averages := s.averages[:0]
+        next := 0
+        for p, timestamp := range s.index.Axis {
+            var value float64
+            reported := false
+            if s.totals[d] != nil && s.reported[d][p] {
+                value, reported = s.totals[d][p], true
+            }
+            if next < len(points) && points[next].position == p {
+                value += points[next].value
+                reported = true
+                next++
+            }
+            if !reported {
+                continue
+            }
+
+            if windowCount == 0 && len(averages) == 0 {
+                windowStart, peak = timestamp, value
+            } else if elapsed := timestamp.Sub(windowStart); elapsed >= interval {
+                averages = append(averages, windowSum/float64(windowCount))
+                windowStart = windowStart.Add(elapsed / interval * interval)
+                windowSum, windowCount = 0, 0
+            }
+            windowSum += value
+            windowCount++
+            peak = math.Max(peak, value)
+        }
+        averages = append(averages, windowSum/float64(windowCount))
+        s.averages = averages
+        loadedDimensions++
+
+        if capacity := s.host.Capacity[dimension]; capacity > 0 {
+            utilization := peak / capacity
+            result.peakUtilization[dimension] = utilization
+            result.maxUtilization = math.Max(result.maxUtilization, utilization)
+            if utilization > 1 {
+                result.fits = false
+            }
+        }
+
+        scale := s.host.Capacity[dimension]
+        if scale <= 0 {
+            scale = peak
+        }
+        if scale > 0 {
+            scaledVolatility += calculateStandardDeviation(averages) / scale
+        }
+    }
+    if loadedDimensions > 0 {
+        result.volatility = scaledVolatility / float64(loadedDimensions)
+    }
+
+    var costShare float64
+    if averageHostCost > 0 {
+        costShare = (s.cost + workload.TotalCost) / averageHostCost
+    }
+    result.score = result.maxUtilization + weights.Cost*costShare + weights.Volatility*result.volatility
+    return result, nil
+}
+
+// PrintPlacement prints each host's workloads, utilization, cost and volatility.
+func PrintPlacement(placement Placement) {
+    fmt.Println("\nWorkload Placement:")
+    for _, host := range placement.Hosts {
+        fmt.Printf("Host: %s, Workloads: %d, Max Peak Utilization: %.1f%%, Total Cost: %.2f, Volatility: %.4f\n",
+            host.Host, len(host.Workloads), host.MaxUtilization*100, host.TotalCost, host.Volatility)
+        dimensions := make([]string, 0, len(host.PeakUtilization))
+        for dimension := range host.PeakUtilization {
+            dimensions = append(dimensions, dimension)
+        }
+        sort.Strings(dimensions)
+        for _, dimension := range dimensions {
+            fmt.Printf("  Peak Utilization %s: %.1f%%\n", dimension, host.PeakUtilization[dimension]*100)
+        }
+        for _, name := range host.Workloads {
+            fmt.Printf("  %s\n", name)
+        }
+    }
+    if len(placement.Unplaced) > 0 {
+        fmt.Println("\nWorkloads That Fit On No Host:")
+        for _, name := range placement.Unplaced {
+            fmt.Println(name)
+        }
+    }
+}
+
+// WritePlacementToFile writes one row per workload with the host it was assigned to. Unplaced
+// workloads have an empty host.
+func WritePlacementToFile(placement Placement, outputFile string) error {
+    file, err := os.Create(outputFile)
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+
+    writer := csv.NewWriter(file)
+    defer writer.Flush()
+
+    if err := writer.Write([]string{"Host", "Workload", "Host Max Utilization", "Host Total Cost", "Host Volatility"}); err != nil {
+        return err
+    }
+    for _, host := range placement.Hosts {
+        for _, name := range host.Workloads {
+            record := []string{
+                host.Host,
+                name,
+                fmt.Sprintf("%.4f", host.MaxUtilization),
+                fmt.Sprintf("%.2f", host.TotalCost),
+                fmt.Sprintf("%.4f", host.Volatility),
+            }
+            if err := writer.Write(record); err != nil {
+                return err
+            }
+        }
+    }
+    for _, name := range placement.Unplaced {
+        if err := writer.Write([]string{"", name, "", "", ""}); err != nil {
+            return err
+        }
+    }
+    return nil
+}
@@ -0,0 +1,351 @@
+package laplace
+
+import (
+    "bufio"
+    "fmt"
+    "io"
+    "math"
+    "net/http"
+    "os"
+    "path"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// metricNameLabel is the label Prometheus stores a series' metric name in.
+const metricNameLabel = "__name__"
+
+// prometheusTimeout bounds each request to a Prometheus endpoint.
+const prometheusTimeout = 30 * time.Second
+
+// PrometheusOptions maps Prometheus series onto workloads. Metrics selects the metrics imported,
+// mapping each metric name to the load dimension it fills; when empty, every metric is imported
+// as a dimension of its own name. A series belongs to the workload named by its WorkloadLabels
+// values joined with "/", and series missing any of them are skipped, as are series whose labels
+// don't equal every value of Matchers. Series landing on the same workload and dimension, such as
+// one per CPU core, are summed at each timestamp.
+//
+// Start and End bound the samples queried from a remote-read endpoint. Timestamp stamps the
+// samples of a text exposition that carry no timestamp of their own; it defaults to the time of
+// reading.
+type PrometheusOptions struct {
+    Metrics        map[string]string
+    WorkloadLabels []string
+    Matchers       map[string]string
+    Start          time.Time
+    End            time.Time
+    Timestamp      time.Time
+}
+
+// DefaultPrometheusOptions imports every metric, with one workload per scraped instance.
+func DefaultPrometheusOptions() PrometheusOptions {
+    return PrometheusOptions{WorkloadLabels: []string{"instance"}}
+}
+
+// PrometheusSeries is one decoded Prometheus series. Labels holds the metric name under
+// "__name__" along with the other labels.
+type PrometheusSeries struct {
+    Labels  map[string]string
+    Samples []TimedValue
+}
+
+// PrometheusTextReader reads FormatPrometheus.
+type PrometheusTextReader struct {
+    Options PrometheusOptions
+}
+
+// PrometheusRemoteReadReader reads FormatRemoteRead.
+type PrometheusRemoteReadReader struct {
+    Options PrometheusOptions
+}
+
+// Read decodes a text exposition and maps its series onto workloads.
+func (p PrometheusTextReader) Read(r io.Reader) (*Data, error) {
+    timestamp := p.Options.Timestamp
+    if timestamp.IsZero() {
+        timestamp = time.Now()
+    }
+    series, err := DecodePrometheusText(r, timestamp)
+    if err != nil {
+        return nil, err
+    }
+    return MapPrometheusSeries(series, p.Options)
+}
+
+// Read decodes a remote-read response, snappy-compressed as served or not, and maps its series
+// onto workloads.
+func (p PrometheusRemoteReadReader) Read(r io.Reader) (*Data, error) {
+    body, err := io.ReadAll(r)
+    if err != nil {
+        return nil, err
+    }
+    series, err := DecodeRemoteReadResponse(body)
+    if err != nil {
+        return nil, err
+    }
+    return MapPrometheusSeries(series, p.Options)
+}
+
+// PrometheusFormat returns format if it is set, and otherwise the format of source: FormatRemoteRead
+// for URLs whose path ends in /read and files ending in .pb, and FormatPrometheus for anything else.
+func PrometheusFormat(source, format string) string {
+    if format != "" {
+        return format
+    }
+    if isURL(source) {
+        if path.Base(strings.SplitN(source, "?", 2)[0]) == "read" {
+            return FormatRemoteRead
+        }
+        return FormatPrometheus
+    }
+    if strings.EqualFold(path.Ext(source), ".pb") {
+        return FormatRemoteRead
+    }
+    return FormatPrometheus
+}
+
+// ImportPrometheus reads the Prometheus series of source, a file or an http(s) URL, in the given
+// format (see PrometheusFormat when empty) and maps them onto workloads ready for
+// CalculateWorkloadStats. A text-format URL is scraped with a GET, such as a /metrics endpoint or
+// a federation query, and a remote-read URL such as http://localhost:9090/api/v1/read is queried
+// for the series of opts.Metrics and opts.Matchers between opts.Start and opts.End.
+func ImportPrometheus(source, format string, opts PrometheusOptions) (*Data, error) {
+    format = PrometheusFormat(source, format)
+    if format != FormatPrometheus && format != FormatRemoteRead {
+        return nil, fmt.Errorf("unknown Prometheus format %q: want %s or %s", format, FormatPrometheus, FormatRemoteRead)
+    }
+    var reader WorkloadReader = PrometheusTextReader{Options: opts}
+    if format == FormatRemoteRead {
+        reader = PrometheusRemoteReadReader{Options: opts}
+    }
+    if !isURL(source) {
+        file, err := os.Open(source)
+        if err != nil {
+            return nil, err
+        }
+        defer file.Close()
+        return reader.Read(file)
+    }
+
+    client := &http.Client{Timeout: prometheusTimeout}
+    if format == FormatRemoteRead {
+        series, err := queryRemoteRead(client, source, opts)
+        if err != nil {
+            return nil, err
+        }
+        return MapPrometheusSeries(series, opts)
+    }
+
+    response, err := client.Get(source)
+    if err != nil {
+        return nil, err
+    }
+    defer response.Body.Close()
+    if err := checkResponse(response); err != nil {
+        return nil, err
+    }
+    return reader.Read(response.Body)
+}
+
+func isURL(source string) bool {
+    return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// checkResponse returns an error carrying the start of the body when a request didn't succeed.
+func checkResponse(response *http.Response) error {
+    if response.StatusCode == http.StatusOK {
+        return nil
+    }
+    body, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+    return fmt.Errorf("%s: %s: %s", response.Request.URL, response.Status, strings.TrimSpace(string(body)))
+}
+
+// MapPrometheusSeries maps series onto workloads as opts says. Workloads are returned in the order
+// their first series appears, and each load is sorted by time. NaN samples, which Prometheus writes
+// to mark stale series, and infinite samples are left out. A series mapped onto the reserved
+// TotalDimension is an error.
+func MapPrometheusSeries(series []PrometheusSeries, opts PrometheusOptions) (*Data, error) {
+    type pointKey struct {
+        workload, dimension string
+        timestamp           int64
+    }
+    builder := newDataBuilder()
+    points := make(map[pointKey]int) // index of each point in its load
+    for _, s := range series {
+        dimension, selected := prometheusDimension(s.Labels, opts)
+        if !selected {
+            continue
+        }
+        if err := CheckDimension(dimension); err != nil {
+            return nil, fmt.Errorf("metric %s: %w", s.Labels[metricNameLabel], err)
+        }
+        name, found := prometheusWorkload(s.Labels, opts.WorkloadLabels)
+        if !found {
+            continue
+        }
+
+        workload := builder.workload(name)
+        for _, sample := range s.Samples {
+            if math.IsNaN(sample.Value) || math.IsInf(sample.Value, 0) {
+                continue
+            }
+            key := pointKey{name, dimension, sample.Timestamp.UnixNano()}
+            if i, exists := points[key]; exists {
+                workload.Loads[dimension][i].Value += sample.Value
+                continue
+            }
+            points[key] = len(workload.Loads[dimension])
+            workload.Loads[dimension] = append(workload.Loads[dimension], sample)
+        }
+    }
+
+    data := builder.data()
+    for _, workload := range data.Workloads {
+        for _, load := range workload.Loads {
+            sort.SliceStable(load, func(i, j int) bool {
+                return load[i].Timestamp.Before(load[j].Timestamp)
+            })
+        }
+    }
+    return data, nil
+}
+
+// prometheusDimension returns the dimension a series fills and whether the options select it.
+func prometheusDimension(labels map[string]string, opts PrometheusOptions) (string, bool) {
+    for name, value := range opts.Matchers {
+        if labels[name] != value {
+            return "", false
+        }
+    }
+    metric := labels[metricNameLabel]
+    if len(opts.Metrics) == 0 {
+        return metric, metric != ""
+    }
+    dimension, selected := opts.Metrics[metric]
+    return dimension, selected
+}
+
+// prometheusWorkload returns the workload name of a series and whether it has every label needed.
+func prometheusWorkload(labels map[string]string, workloadLabels []string) (string, bool) {
+    values := make([]string, len(workloadLabels))
+    for i, name := range workloadLabels {
+        value, exists := labels[name]
+        if !exists || value == "" {
+            return "", false
+        }
+        values[i] = value
+    }
+    return strings.Join(values, "/"), len(values) > 0
+}
+
+// DecodePrometheusText decodes the samples of a Prometheus text exposition, or of an OpenMetrics
+// one, as one series per line. Comments and blank lines are skipped. Samples without a timestamp
+// are stamped with timestamp.
+func DecodePrometheusText(r io.Reader, timestamp time.Time) ([]PrometheusSeries, error) {
+    scanner := bufio.NewScanner(r)
+    scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+    var series []PrometheusSeries
+    for line := 1; scanner.Scan(); line++ {
+        text := strings.TrimSpace(scanner.Text())
+        if text == "" || strings.HasPrefix(text, "#") {
+            continue
+        }
+        s, err := parsePrometheusSample(text, timestamp)
+        if err != nil {
+            return nil, fmt.Errorf("line %d: %w", line, err)
+        }
+        series = append(series, s)
+    }
+    if err := scanner.Err(); err != nil {
+        return nil, err
+    }
+    return series, nil
+}
+
+// parsePrometheusSample parses one sample line: a metric name, optional {labels}, a value, an
+// optional timestamp in milliseconds and, in OpenMetrics, an optional exemplar after a "#", which
+// is ignored.
+func parsePrometheusSample(text string, timestamp time.Time) (PrometheusSeries, error) {
+    end := strings.IndexAny(text, "{ \t")
+    if end <= 0 {
+        return PrometheusSeries{}, fmt.Errorf("no value in %q", text)
+    }
+    labels := map[string]string{metricNameLabel: text[:end]}
+    rest := text[end:]
+    if strings.HasPrefix(rest, "{") {
+        var err error
+        if rest, err = parsePrometheusLabels(rest[1:], labels); err != nil {
+            return PrometheusSeries{}, err
+        }
+    }
+
+    if exemplar := strings.IndexByte(rest, '#'); exemplar >= 0 {
+        rest = rest[:exemplar]
+    }
+    fields := strings.Fields(rest)
+    if len(fields) == 0 || len(fields) > 2 {
+        return PrometheusSeries{}, fmt.Errorf("want a value and an optional timestamp after the labels, got %q", rest)
+    }
+    value, err := strconv.ParseFloat(fields[0], 64)
+    if err != nil {
+        return PrometheusSeries{}, err
+    }
+    if len(fields) == 2 {
+        milliseconds, err := strconv.ParseInt(fields[1], 10, 64)
+        if err != nil {
+            return PrometheusSeries{}, fmt.Errorf("bad timestamp %q: %w", fields[1], err)
+        }
+        timestamp = time.UnixMilli(milliseconds).UTC()
+    }
+    return PrometheusSeries{Labels: labels, Samples: []TimedValue{{Timestamp: timestamp, Value: value}}}, nil
+}
+
+// parsePrometheusLabels parses the label pairs following a "{" into labels and returns the text
+// after the closing "}".
+func parsePrometheusLabels(text string, labels map[string]string) (string, error) {
+    for {
+        text = strings.TrimLeft(text, " \t")
+        if strings.HasPrefix(text, "}") {
+            return text[1:], nil
+        }
+        equals := strings.IndexByte(text, '=')
+        if equals <= 0 {
+            return "", fmt.Errorf("bad label in %q", text)
+        }
+        name := strings.TrimSpace(text[:equals])
+        text = strings.TrimLeft(text[equals+1:], " \t")
+        if !strings.HasPrefix(text, `"`) {
+            return "", fmt.Errorf("label %s has no quoted value", name)
+        }
+
+        var value strings.Builder
+        i := 1
+        for ; i < len(text) && text[i] != '"'; i++ {
+            if text[i] == '\\' && i+1 < len(text) {
+                i++
+                switch text[i] {
+                case 'n':
+                    value.WriteByte('\n')
+                default:
+                    value.WriteByte(text[i])
+                }
+                continue
+            }
+            value.WriteByte(text[i])
+        }
+        if i == len(text) {
+            return "", fmt.Errorf("label %s has an unterminated value", name)
+        }
+        labels[name] = value.String()
+
+        text = strings.TrimLeft(text[i+1:], " \t")
+        if strings.HasPrefix(text, ",") {
+            text = text[1:]
+        } else if !strings.HasPrefix(text, "}") {
+            return "", fmt.Errorf("want , or } after label %s", name)
+        }
+    }
+}
@@ -0,0 +1,266 @@
+package laplace
+
+import (
+    "encoding/binary"
+    "io"
+    "math"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+)
+
+func TestParsePrometheusSample(t *testing.T) {
+    scraped := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+    tests := []struct {
+        name      string
+        text      string
+        labels    map[string]string
+        value     float64
+        timestamp time.Time
+    }{
+        {
+            name:      "bare",
+            text:      "up 1",
+            labels:    map[string]string{metricNameLabel: "up"},
+            value:     1,
+            timestamp: scraped,
+        },
+        {
+            name:      "labels and timestamp",
+            text:      `node_cpu_seconds_total{cpu="0", mode="user"} 42.5 1714564800000`,
+            labels:    map[string]string{metricNameLabel: "node_cpu_seconds_total", "cpu": "0", "mode": "user"},
+            value:     42.5,
+            timestamp: time.UnixMilli(1714564800000).UTC(),
+        },
+        {
+            name:      "escaped label value",
+            text:      `http_requests_total{path="/a\"b\\c\n",} 3`,
+            labels:    map[string]string{metricNameLabel: "http_requests_total", "path": "/a\"b\\c\n"},
+            value:     3,
+            timestamp: scraped,
+        },
+        {
+            name:      "exemplar",
+            text:      `http_request_duration_seconds_bucket{le="0.5"} 7 # {trace_id="x"} 1`,
+            labels:    map[string]string{metricNameLabel: "http_request_duration_seconds_bucket", "le": "0.5"},
+            value:     7,
+            timestamp: scraped,
+        },
+        {
+            name:      "exemplar after a timestamp",
+            text:      `http_request_duration_seconds_bucket{le="+Inf"} 9 1714564800000 # {trace_id="x"} 1.2 1714564799.5`,
+            labels:    map[string]string{metricNameLabel: "http_request_duration_seconds_bucket", "le": "+Inf"},
+            value:     9,
+            timestamp: time.UnixMilli(1714564800000).UTC(),
+        },
+    }
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            series, err := parsePrometheusSample(test.text, scraped)
+            if err != nil {
+                t.Fatalf("parsePrometheusSample(%q): %v", test.text, err)
+            }
+            if len(series.Labels) != len(test.labels) {
+                t.Errorf("labels = %v, want %v", series.Labels, test.labels)
+            }
+            for name, value := range test.labels {
+                if series.Labels[name] != value {
+                    t.Errorf("label %s = %q, want %q", name, series.Labels[name], value)
+                }
+            }
+            if len(series.Samples) != 1 {
+                t.Fatalf("got %d samples, want 1", len(series.Samples))
+            }
+            sample := series.Samples[0]
+            if sample.Value != test.value || !sample.Timestamp.Equal(test.timestamp) {
+                t.Errorf("sample = %v at %s, want %v at %s", sample.Value, sample.Timestamp, test.value, test.timestamp)
+            }
+        })
+    }
+}
+
+func TestParsePrometheusSampleErrors(t *testing.T) {
+    for _, text := range []string{
+        "up",
+        "up 1 2 3",
+        "up one",
+        "up 1 later",
+        `up{job="a" 1`,
+        `up{job=a} 1`,
+    } {
+        if _, err := parsePrometheusSample(text, time.Now()); err == nil {
+            t.Errorf("parsePrometheusSample(%q) succeeded, want an error", text)
+        }
+    }
+}
+
+func TestImportPrometheusText(t *testing.T) {
+    exposition := `# HELP node_cpu_seconds_total Seconds the CPUs spent in each mode.
+# TYPE node_cpu_seconds_total counter
+node_cpu_seconds_total{instance="web-1",cpu="0"} 10 1714564800000
+node_cpu_seconds_total{instance="web-1",cpu="1"} 5 1714564800000
+node_memory_bytes{instance="web-1"} 2048 1714564800000
+node_cpu_seconds_total{instance="web-2",cpu="0"} 7 1714564800000
+node_cpu_seconds_total{cpu="0"} 99 1714564800000
+`
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path != "/metrics" {
+            http.NotFound(w, r)
+            return
+        }
+        io.WriteString(w, exposition)
+    }))
+    defer server.Close()
+
+    opts := DefaultPrometheusOptions()
+    opts.Metrics = map[string]string{"node_cpu_seconds_total": "cpu", "node_memory_bytes": "mem"}
+    data, err := ImportPrometheus(server.URL+"/metrics", "", opts)
+    if err != nil {
+        t.Fatalf("ImportPrometheus: %v", err)
+    }
+
+    timestamp := time.UnixMilli(1714564800000).UTC()
+    want := []Workload{
+        {Name: "web-1", Loads: map[string][]TimedValue{"cpu": {{timestamp, 15}}, "mem": {{timestamp, 2048}}}},
+        {Name: "web-2", Loads: map[string][]TimedValue{"cpu": {{timestamp, 7}}}},
+    }
+    checkWorkloads(t, data, want)
+
+    if _, err := ImportPrometheus(server.URL+"/missing", "", opts); err == nil {
+        t.Error("ImportPrometheus succeeded on a missing endpoint, want an error")
+    }
+}
+
+func TestImportPrometheusRemoteRead(t *testing.T) {
+    start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+    end := start.Add(time.Hour)
+    samples := []TimedValue{
+        {start.Add(2 * time.Minute), 4},
+        {start.Add(time.Minute), 3.5},
+        {start.Add(3 * time.Minute), math.NaN()},
+    }
+
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost || r.Header.Get("Content-Encoding") != "snappy" {
+            http.Error(w, "want a snappy POST", http.StatusBadRequest)
+            return
+        }
+        body, err := io.ReadAll(r.Body)
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+        request, err := snappyDecode(body)
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+        queryStart, queryEnd, err := decodeQueryRange(request)
+        if err != nil || queryStart != start.UnixMilli() || queryEnd != end.UnixMilli() {
+            http.Error(w, "unexpected query range", http.StatusBadRequest)
+            return
+        }
+
+        series := encodeTimeSeries(map[string]string{metricNameLabel: "container_cpu", "instance": "db-1"}, samples)
+        response := appendBytesField(nil, 1, appendBytesField(nil, 1, series))
+        w.Header().Set("Content-Type", "application/x-protobuf")
+        w.Header().Set("Content-Encoding", "snappy")
+        w.Write(snappyEncode(response))
+    }))
+    defer server.Close()
+
+    opts := DefaultPrometheusOptions()
+    opts.Metrics = map[string]string{"container_cpu": "cpu"}
+    opts.Start, opts.End = start, end
+    data, err := ImportPrometheus(server.URL+"/api/v1/read", "", opts)
+    if err != nil {
+        t.Fatalf("ImportPrometheus: %v", err)
+    }
+
+    want := []Workload{
+        {Name: "db-1", Loads: map[string][]TimedValue{"cpu": {samples[1], samples[0]}}},
+    }
+    checkWorkloads(t, data, want)
+}
+
+func TestSnappyRoundTrip(t *testing.T) {
+    for _, size := range []int{0, 1, 60, 61, 256, 257, 1 << 16, 1<<16 + 1, 200000} {
+        src := make([]byte, size)
+        for i := range src {
+            src[i] = byte(i * 7)
+        }
+        decoded, err := snappyDecode(snappyEncode(src))
+        if err != nil {
+            t.Fatalf("size %d: %v", size, err)
+        }
+        if string(decoded) != string(src) {
+            t.Errorf("size %d: round trip changed the bytes", size)
+        }
+    }
+}
+
+// checkWorkloads compares the names and loads of data's workloads with want, in order.
+func checkWorkloads(t *testing.T, data *Data, want []Workload) {
+    t.Helper()
+    if len(data.Workloads) != len(want) {
+        t.Fatalf("got %d workloads, want %d", len(data.Workloads), len(want))
+    }
+    for i, workload := range data.Workloads {
+        if workload.Name != want[i].Name {
+            t.Errorf("workload %d is %q, want %q", i, workload.Name, want[i].Name)
+            continue
+        }
+        if len(workload.Loads) != len(want[i].Loads) {
+            t.Errorf("%s has dimensions %v, want %v", workload.Name, workload.Dimensions(), want[i].Dimensions())
+        }
+        for dimension, load := range want[i].Loads {
+            got := workload.Loads[dimension]
+            if len(got) != len(load) {
+                t.Errorf("%s %s has %d values, want %d", workload.Name, dimension, len(got), len(load))
+                continue
+            }
+            for j := range load {
+                if !got[j].Timestamp.Equal(load[j].Timestamp) || got[j].Value != load[j].Value {
+                    t.Errorf("%s %s value %d = %v at %s, want %v at %s", workload.Name, dimension, j, got[j].Value, got[j].Timestamp, load[j].Value, load[j].Timestamp)
+                }
+            }
+        }
+    }
+}
+
+// decodeQueryRange returns the start and end, in milliseconds, of the query of a ReadRequest.
+func decodeQueryRange(request []byte) (start, end int64, err error) {
+    err = forEachField(request, func(field int, value []byte, _ uint64) error {
+        if field != 1 { // queries
+            return nil
+        }
+        return forEachField(value, func(field int, _ []byte, number uint64) error {
+            switch field {
+            case 1:
+                start = int64(number)
+            case 2:
+                end = int64(number)
+            }
+            return nil
+        })
+    })
+    return start, end, err
+}
+
+// encodeTimeSeries encodes a TimeSeries as a remote-read endpoint returns it.
+func encodeTimeSeries(labels map[string]string, samples []TimedValue) []byte {
+    var series []byte
+    for name, value := range labels {
+        label := appendBytesField(nil, 1, []byte(name))
+        label = appendBytesField(label, 2, []byte(value))
+        series = appendBytesField(series, 1, label)
+    }
+    for _, sample := range samples {
+        encoded := binary.AppendUvarint(nil, 1<<3|wireFixed64)
+        encoded = binary.LittleEndian.AppendUint64(encoded, math.Float64bits(sample.Value))
+        encoded = appendVarintField(encoded, 2, uint64(sample.Timestamp.UnixMilli()))
+        series = appendBytesField(series, 2, encoded)
+    }
+    return series
+}
@@ -0,0 +1,305 @@
+package laplace
+
+import (
+    "bufio"
+    "encoding/csv"
+    "encoding/json"
+    "fmt"
+    "io"
+    "math"
+    "os"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// Input formats read by LoadFile.
+const (
+    // FormatJSON is the {"workloads": [...]} layout written by generate.
+    FormatJSON = "json"
+    // FormatCSV is long-format CSV with one load value per row: timestamp, workload, dimension and
+    // value columns, named in a header row, and an optional valueGenerated column.
+    FormatCSV = "csv"
+    // FormatJSONL is JSON Lines holding one object per line: either a workload in the layout of
+    // FormatJSON's workloads, or one load value with timestamp, workload, dimension and value
+    // fields and an optional valueGenerated.
+    FormatJSONL = "jsonl"
+    // FormatPrometheus is a Prometheus text exposition, such as a saved /metrics scrape, mapped
+    // onto workloads with DefaultPrometheusOptions.
+    FormatPrometheus = "prom"
+    // FormatRemoteRead is a Prometheus remote-read response, mapped onto workloads with
+    // DefaultPrometheusOptions.
+    FormatRemoteRead = "remote-read"
+)
+
+// WorkloadReader decodes workloads from one input format.
+type WorkloadReader interface {
+    Read(r io.Reader) (*Data, error)
+}
+
+// JSONReader reads FormatJSON.
+type JSONReader struct{}
+
+// CSVReader reads FormatCSV.
+type CSVReader struct{}
+
+// JSONLReader reads FormatJSONL.
+type JSONLReader struct{}
+
+// NewWorkloadReader returns the reader of format.
+func NewWorkloadReader(format string) (WorkloadReader, error) {
+    switch format {
+    case FormatJSON:
+        return JSONReader{}, nil
+    case FormatCSV:
+        return CSVReader{}, nil
+    case FormatJSONL:
+        return JSONLReader{}, nil
+    case FormatPrometheus:
+        return PrometheusTextReader{Options: DefaultPrometheusOptions()}, nil
+    case FormatRemoteRead:
+        return PrometheusRemoteReadReader{Options: DefaultPrometheusOptions()}, nil
+    }
+    return nil, fmt.Errorf("unknown input format %q: want %s, %s, %s, %s or %s", format, FormatJSON, FormatCSV, FormatJSONL, FormatPrometheus, FormatRemoteRead)
+}
+
+// FileFormat returns format if it is set, and otherwise the format implied by the file's
+// extension: .csv for FormatCSV, .jsonl or .ndjson for FormatJSONL, .prom for FormatPrometheus, .pb
+// for FormatRemoteRead and anything else FormatJSON.
+func FileFormat(filename, format string) string {
+    if format != "" {
+        return format
+    }
+    switch strings.ToLower(filepath.Ext(filename)) {
+    case ".csv":
+        return FormatCSV
+    case ".jsonl", ".ndjson":
+        return FormatJSONL
+    case ".prom":
+        return FormatPrometheus
+    case ".pb":
+        return FormatRemoteRead
+    }
+    return FormatJSON
+}
+
+// LoadFile loads the workloads in a file of the given format, or of the format implied by its
+// extension when format is empty.
+func LoadFile(filename, format string) (*Data, error) {
+    reader, err := NewWorkloadReader(FileFormat(filename, format))
+    if err != nil {
+        return nil, err
+    }
+    file, err := os.Open(filename)
+    if err != nil {
+        return nil, err
+    }
+    defer file.Close()
+
+    return reader.Read(file)
+}
+
+// Read decodes the {"workloads": [...]} layout.
+func (JSONReader) Read(r io.Reader) (*Data, error) {
+    return DecodeData(r)
+}
+
+// Read decodes long-format CSV. Workloads are returned in the order they first appear and their
+// values in the order of the rows.
+func (CSVReader) Read(r io.Reader) (*Data, error) {
+    reader := csv.NewReader(r)
+    header, err := reader.Read()
+    if err == io.EOF {
+        return &Data{}, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    columns, err := csvColumns(header)
+    if err != nil {
+        return nil, err
+    }
+    valueGeneratedColumn, hasValueGenerated := columns["valuegenerated"]
+
+    builder := newDataBuilder()
+    for line := 2; ; line++ {
+        record, err := reader.Read()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            return nil, err
+        }
+
+        point := loadPoint{
+            Timestamp: record[columns["timestamp"]],
+            Workload:  record[columns["workload"]],
+            Dimension: record[columns["dimension"]],
+        }
+        value, err := parseLoadValue(record[columns["value"]])
+        if err != nil {
+            return nil, fmt.Errorf("line %d: %w", line, err)
+        }
+        point.Value = &value
+        if hasValueGenerated && strings.TrimSpace(record[valueGeneratedColumn]) != "" {
+            valueGenerated, err := parseLoadValue(record[valueGeneratedColumn])
+            if err != nil {
+                return nil, fmt.Errorf("line %d: valueGenerated: %w", line, err)
+            }
+            point.ValueGenerated = &valueGenerated
+        }
+        if err := builder.add(point); err != nil {
+            return nil, fmt.Errorf("line %d: %w", line, err)
+        }
+    }
+    return builder.data(), nil
+}
+
+// csvColumns returns the index of each column of a long-format CSV header by lower-case name.
+func csvColumns(header []string) (map[string]int, error) {
+    columns := make(map[string]int)
+    for i, name := range header {
+        columns[strings.ToLower(strings.TrimSpace(name))] = i
+    }
+    for _, required := range []string{"timestamp", "workload", "dimension", "value"} {
+        if _, exists := columns[required]; !exists {
+            return nil, fmt.Errorf("CSV header has no %s column", required)
+        }
+    }
+    return columns, nil
+}
+
+// Read decodes JSON Lines. Blank lines are skipped. Workloads are returned in the order they first
+// appear; a workload given as a whole object is merged with the values given for it one by one.
+func (JSONLReader) Read(r io.Reader) (*Data, error) {
+    scanner := bufio.NewScanner(r)
+    scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+    builder := newDataBuilder()
+    for line := 1; scanner.Scan(); line++ {
+        text := strings.TrimSpace(scanner.Text())
+        if text == "" {
+            continue
+        }
+
+        var fields map[string]json.RawMessage
+        if err := json.Unmarshal([]byte(text), &fields); err != nil {
+            return nil, fmt.Errorf("line %d: %w", line, err)
+        }
+        if _, isPoint := lookupField(fields, "dimension"); isPoint {
+            var point loadPoint
+            if err := json.Unmarshal([]byte(text), &point); err != nil {
+                return nil, fmt.Errorf("line %d: %w", line, err)
+            }
+            if err := builder.add(point); err != nil {
+                return nil, fmt.Errorf("line %d: %w", line, err)
+            }
+            continue
+        }
+
+        var workload Workload
+        if err := json.Unmarshal([]byte(text), &workload); err != nil {
+            return nil, fmt.Errorf("line %d: %w", line, err)
+        }
+        builder.addWorkload(workload)
+    }
+    if err := scanner.Err(); err != nil {
+        return nil, err
+    }
+    return builder.data(), nil
+}
+
+// lookupField returns the field named name, matching case-insensitively as encoding/json does.
+func lookupField(fields map[string]json.RawMessage, name string) (json.RawMessage, bool) {
+    for key, value := range fields {
+        if strings.EqualFold(key, name) {
+            return value, true
+        }
+    }
+    return nil, false
+}
+
+// loadPoint is one load value of a long-format input.
+type loadPoint struct {
+    Timestamp      string   `json:"timestamp"`
+    Workload       string   `json:"workload"`
+    Dimension      string   `json:"dimension"`
+    Value          *float64 `json:"value"`
+    ValueGenerated *float64 `json:"valueGenerated"`
+}
+
+// parseLoadValue parses a load value, rejecting NaN and infinities.
+func parseLoadValue(text string) (float64, error) {
+    value, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+    if err != nil {
+        return 0, err
+    }
+    if math.IsNaN(value) || math.IsInf(value, 0) {
+        return 0, fmt.Errorf("value %q is not a finite number", text)
+    }
+    return value, nil
+}
+
+// dataBuilder collects workloads from long-format inputs in the order they first appear.
+type dataBuilder struct {
+    workloads []Workload
+    indexes   map[string]int
+}
+
+func newDataBuilder() *dataBuilder {
+    return &dataBuilder{indexes: make(map[string]int)}
+}
+
+// workload returns the workload named name, adding it if it's new.
+func (b *dataBuilder) workload(name string) *Workload {
+    i, exists := b.indexes[name]
+    if !exists {
+        i = len(b.workloads)
+        b.indexes[name] = i
+        b.workloads = append(b.workloads, Workload{Name: name, Loads: make(map[string][]TimedValue)})
+    }
+    return &b.workloads[i]
+}
+
+// add appends one load value to its workload.
+func (b *dataBuilder) add(point loadPoint) error {
+    if point.Workload == "" {
+        return fmt.Errorf("no workload given")
+    }
+    if point.Dimension == "" {
+        return fmt.Errorf("no dimension given")
+    }
+    if err := CheckDimension(point.Dimension); err != nil {
+        return err
+    }
+    if point.Value == nil {
+        return fmt.Errorf("no value given")
+    }
+    timestamp, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(point.Timestamp))
+    if err != nil {
+        return err
+    }
+    workload := b.workload(point.Workload)
+    workload.Loads[point.Dimension] = append(workload.Loads[point.Dimension], TimedValue{Timestamp: timestamp, Value: *point.Value})
+    if point.ValueGenerated != nil {
+        workload.ValueGenerated = *point.ValueGenerated
+    }
+    return nil
+}
+
+// addWorkload merges a whole workload into the one of the same name.
+func (b *dataBuilder) addWorkload(added Workload) {
+    workload := b.workload(added.Name)
+    for dimension, load := range added.Loads {
+        workload.Loads[dimension] = append(workload.Loads[dimension], load...)
+    }
+    if added.ValueGenerated != 0 {
+        workload.ValueGenerated = added.ValueGenerated
+    }
+}
+
+func (b *dataBuilder) data() *Data {
+    return &Data{Workloads: b.workloads}
+}
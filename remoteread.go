@@ -0,0 +1,344 @@
+package laplace
+
+import (
+    "bytes"
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "io"
+    "math"
+    "net/http"
+    "regexp"
+    "sort"
+    "strings"
+    "time"
+)
+
+// The remote-read protocol exchanges snappy-compressed protocol buffers. Only the handful of
+// messages the importer needs are encoded and decoded here, by hand, so the package keeps to the
+// standard library. Field numbers follow prometheus/prompb remote.proto and types.proto.
+
+// Label matcher types of a remote-read query.
+const (
+    matchEqual  = 0
+    matchRegexp = 2
+)
+
+// Protocol buffer wire types.
+const (
+    wireVarint  = 0
+    wireFixed64 = 1
+    wireBytes   = 2
+    wireFixed32 = 5
+)
+
+// defaultRemoteReadRange is how far back a remote-read query reaches when no Start is given.
+const defaultRemoteReadRange = time.Hour
+
+// queryRemoteRead posts a remote-read query for the series selected by opts to url and decodes the
+// series returned.
+func queryRemoteRead(client *http.Client, url string, opts PrometheusOptions) ([]PrometheusSeries, error) {
+    request, err := encodeReadRequest(opts)
+    if err != nil {
+        return nil, err
+    }
+    httpRequest, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(snappyEncode(request)))
+    if err != nil {
+        return nil, err
+    }
+    httpRequest.Header.Set("Content-Type", "application/x-protobuf")
+    httpRequest.Header.Set("Content-Encoding", "snappy")
+    httpRequest.Header.Set("X-Prometheus-Remote-Read-Version", "0.1.0")
+
+    response, err := client.Do(httpRequest)
+    if err != nil {
+        return nil, err
+    }
+    defer response.Body.Close()
+    if err := checkResponse(response); err != nil {
+        return nil, err
+    }
+    body, err := io.ReadAll(response.Body)
+    if err != nil {
+        return nil, err
+    }
+    return DecodeRemoteReadResponse(body)
+}
+
+// encodeReadRequest encodes a ReadRequest holding one query for the metrics and matchers of opts
+// between opts.Start and opts.End, which default to the last hour.
+func encodeReadRequest(opts PrometheusOptions) ([]byte, error) {
+    end := opts.End
+    if end.IsZero() {
+        end = time.Now()
+    }
+    start := opts.Start
+    if start.IsZero() {
+        start = end.Add(-defaultRemoteReadRange)
+    }
+    if !start.Before(end) {
+        return nil, fmt.Errorf("remote-read start %s must be before end %s", start.Format(time.RFC3339), end.Format(time.RFC3339))
+    }
+    if len(opts.Metrics) == 0 && len(opts.Matchers) == 0 {
+        return nil, errors.New("a remote-read query needs metrics or label matchers to select series")
+    }
+
+    var query []byte
+    query = appendVarintField(query, 1, uint64(start.UnixMilli()))
+    query = appendVarintField(query, 2, uint64(end.UnixMilli()))
+    if len(opts.Metrics) > 0 {
+        metrics := make([]string, 0, len(opts.Metrics))
+        for metric := range opts.Metrics {
+            metrics = append(metrics, regexp.QuoteMeta(metric))
+        }
+        sort.Strings(metrics)
+        query = appendBytesField(query, 3, encodeMatcher(matchRegexp, metricNameLabel, strings.Join(metrics, "|")))
+    }
+    names := make([]string, 0, len(opts.Matchers))
+    for name := range opts.Matchers {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    for _, name := range names {
+        query = appendBytesField(query, 3, encodeMatcher(matchEqual, name, opts.Matchers[name]))
+    }
+    return appendBytesField(nil, 1, query), nil
+}
+
+// encodeMatcher encodes a LabelMatcher.
+func encodeMatcher(matchType uint64, name, value string) []byte {
+    var matcher []byte
+    matcher = appendVarintField(matcher, 1, matchType)
+    matcher = appendBytesField(matcher, 2, []byte(name))
+    return appendBytesField(matcher, 3, []byte(value))
+}
+
+func appendVarintField(b []byte, field int, value uint64) []byte {
+    b = binary.AppendUvarint(b, uint64(field)<<3|wireVarint)
+    return binary.AppendUvarint(b, value)
+}
+
+func appendBytesField(b []byte, field int, value []byte) []byte {
+    b = binary.AppendUvarint(b, uint64(field)<<3|wireBytes)
+    b = binary.AppendUvarint(b, uint64(len(value)))
+    return append(b, value...)
+}
+
+// DecodeRemoteReadResponse decodes the series of every query result of a sampled remote-read
+// ReadResponse, whether snappy-compressed as served over HTTP or already decompressed.
+// Chunked streaming responses are not supported.
+func DecodeRemoteReadResponse(body []byte) ([]PrometheusSeries, error) {
+    if decompressed, err := snappyDecode(body); err == nil {
+        if series, err := decodeReadResponse(decompressed); err == nil {
+            return series, nil
+        }
+    }
+    series, err := decodeReadResponse(body)
+    if err != nil {
+        return nil, fmt.Errorf("decoding remote-read response: %w", err)
+    }
+    return series, nil
+}
+
+// decodeReadResponse decodes an uncompressed ReadResponse.
+func decodeReadResponse(body []byte) ([]PrometheusSeries, error) {
+    var series []PrometheusSeries
+    err := forEachField(body, func(field int, value []byte, _ uint64) error {
+        if field != 1 { // results
+            return nil
+        }
+        return forEachField(value, func(field int, value []byte, _ uint64) error {
+            if field != 1 { // timeseries
+                return nil
+            }
+            s, err := decodeTimeSeries(value)
+            if err != nil {
+                return err
+            }
+            series = append(series, s)
+            return nil
+        })
+    })
+    return series, err
+}
+
+// decodeTimeSeries decodes a TimeSeries of labels and samples.
+func decodeTimeSeries(body []byte) (PrometheusSeries, error) {
+    series := PrometheusSeries{Labels: make(map[string]string)}
+    err := forEachField(body, func(field int, value []byte, _ uint64) error {
+        switch field {
+        case 1: // labels
+            var name, labelValue string
+            err := forEachField(value, func(field int, value []byte, _ uint64) error {
+                switch field {
+                case 1:
+                    name = string(value)
+                case 2:
+                    labelValue = string(value)
+                }
+                return nil
+            })
+            series.Labels[name] = labelValue
+            return err
+        case 2: // samples
+            var sample TimedValue
+            err := forEachField(value, func(field int, _ []byte, number uint64) error {
+                switch field {
+                case 1:
+                    sample.Value = math.Float64frombits(number)
+                case 2:
+                    sample.Timestamp = time.UnixMilli(int64(number)).UTC()
+                }
+                return nil
+            })
+            series.Samples = append(series.Samples, sample)
+            return err
+        }
+        return nil
+    })
+    return series, err
+}
+
+// forEachField calls fn with each field of a protocol buffer message: the contents of
+// length-delimited fields as value, and the number held by the others as number.
+func forEachField(message []byte, fn func(field int, value []byte, number uint64) error) error {
+    for len(message) > 0 {
+        key, n := binary.Uvarint(message)
+        if n <= 0 {
+            return errors.New("bad field key")
+        }
+        message = message[n:]
+        field := int(key >> 3)
+        if field == 0 {
+            return errors.New("bad field number 0")
+        }
+
+        var value []byte
+        var number uint64
+        switch key & 7 {
+        case wireVarint:
+            if number, n = binary.Uvarint(message); n <= 0 {
+                return fmt.Errorf("field %d: bad varint", field)
+            }
+            message = message[n:]
+        case wireFixed64:
+            if len(message) < 8 {
+                return fmt.Errorf("field %d: truncated", field)
+            }
+            number = binary.LittleEndian.Uint64(message)
+            message = message[8:]
+        case wireFixed32:
+            if len(message) < 4 {
+                return fmt.Errorf("field %d: truncated", field)
+            }
+            number = uint64(binary.LittleEndian.Uint32(message))
+            message = message[4:]
+        case wireBytes:
+            length, n := binary.Uvarint(message)
+            if n <= 0 || uint64(len(message)-n) < length {
+                return fmt.Errorf("field %d: truncated", field)
+            }
+            value = message[n : n+int(length)]
+            message = message[n+int(length):]
+        default:
+            return fmt.Errorf("field %d: unsupported wire type %d", field, key&7)
+        }
+        if err := fn(field, value, number); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// snappyDecode decompresses a snappy block.
+func snappyDecode(src []byte) ([]byte, error) {
+    length, n := binary.Uvarint(src)
+    if n <= 0 || length > 1<<30 {
+        return nil, errors.New("snappy: bad length")
+    }
+    src = src[n:]
+    dst := make([]byte, 0, length)
+    for len(src) > 0 {
+        tag := src[0]
+        src = src[1:]
+
+        if tag&3 == 0 { // literal
+            size := int(tag >> 2)
+            if size >= 60 {
+                extra := size - 59
+                if len(src) < extra {
+                    return nil, errors.New("snappy: truncated literal length")
+                }
+                size = 0
+                for i := extra - 1; i >= 0; i-- {
+                    size = size<<8 | int(src[i])
+                }
+                src = src[extra:]
+            }
+            size++
+            if size <= 0 || len(src) < size {
+                return nil, errors.New("snappy: truncated literal")
+            }
+            dst = append(dst, src[:size]...)
+            src = src[size:]
+            continue
+        }
+
+        var size, offset int
+        switch tag & 3 {
+        case 1:
+            if len(src) < 1 {
+                return nil, errors.New("snappy: truncated copy")
+            }
+            size = 4 + int(tag>>2&7)
+            offset = int(tag&0xe0)<<3 | int(src[0])
+            src = src[1:]
+        case 2:
+            if len(src) < 2 {
+                return nil, errors.New("snappy: truncated copy")
+            }
+            size = 1 + int(tag>>2)
+            offset = int(binary.LittleEndian.Uint16(src))
+            src = src[2:]
+        case 3:
+            if len(src) < 4 {
+                return nil, errors.New("snappy: truncated copy")
+            }
+            size = 1 + int(tag>>2)
+            offset = int(binary.LittleEndian.Uint32(src))
+            src = src[4:]
+        }
+        if offset <= 0 || offset > len(dst) {
+            return nil, errors.New("snappy: bad copy offset")
+        }
+        // Copies may overlap the bytes they produce, so they go one byte at a time.
+        for start := len(dst) - offset; size > 0; size-- {
+            dst = append(dst, dst[start])
+            start++
+        }
+    }
+    if uint64(len(dst)) != length {
+        return nil, errors.New("snappy: length mismatch")
+    }
+    return dst, nil
+}
+
+// snappyEncode compresses src as a snappy block of literals. Queries are small, so there's
+// nothing to gain from matching repeated bytes.
+func snappyEncode(src []byte) []byte {
+    dst := binary.AppendUvarint(nil, uint64(len(src)))
+    for len(src) > 0 {
+        size := min(len(src), 1<<16)
+        switch {
+        case size <= 60:
+            dst = append(dst, byte(size-1)<<2)
+        case size <= 1<<8:
+            dst = append(dst, 60<<2, byte(size-1))
+        default:
+            dst = append(dst, 61<<2, byte(size-1), byte((size-1)>>8))
+        }
+        dst = append(dst, src[:size]...)
+        src = src[size:]
+    }
+    return dst
+}
@@ -0,0 +1,134 @@
+package laplace
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+    "time"
+)
+
+// Aggregations Resample combines the values falling in one step with.
+const (
+    ResampleSum  = "sum"
+    ResampleMean = "mean"
+    ResampleMax  = "max"
+    ResampleLast = "last"
+)
+
+// ResampleOptions configures Resample. Every series is converted to one value per Step, combined
+// with Aggregation or, for the dimensions listed in Dimensions, with the aggregation given there.
+// Use ResampleSum for loads counted per interval, such as bytes sent, and ResampleMean for loads
+// measured as a level, such as CPU utilization.
+type ResampleOptions struct {
+    Step        time.Duration
+    Aggregation string
+    Dimensions  map[string]string
+}
+
+// ParseResampleAggregation parses an aggregation flag such as "mean" or "mean,net_egress=sum":
+// the entry without a dimension is the default aggregation, the others override it per dimension.
+func ParseResampleAggregation(input string) (string, map[string]string) {
+    aggregation := ResampleMean
+    dimensions := make(map[string]string)
+    for _, entry := range strings.Split(input, ",") {
+        entry = strings.TrimSpace(entry)
+        if entry == "" {
+            continue
+        }
+        dimension, dimensionAggregation, found := strings.Cut(entry, "=")
+        if !found {
+            aggregation = entry
+            continue
+        }
+        dimensions[strings.TrimSpace(dimension)] = strings.TrimSpace(dimensionAggregation)
+    }
+    return aggregation, dimensions
+}
+
+// Validate checks the options.
+func (o ResampleOptions) Validate() error {
+    if o.Step <= 0 {
+        return fmt.Errorf("resample step must be positive, got %v", o.Step)
+    }
+    if err := validateAggregation(o.Aggregation); err != nil {
+        return err
+    }
+    for dimension, aggregation := range o.Dimensions {
+        if err := validateAggregation(aggregation); err != nil {
+            return fmt.Errorf("%s: %w", dimension, err)
+        }
+    }
+    return nil
+}
+
+func validateAggregation(aggregation string) error {
+    switch aggregation {
+    case ResampleSum, ResampleMean, ResampleMax, ResampleLast:
+        return nil
+    }
+    return fmt.Errorf("unknown aggregation %q: want %s, %s, %s or %s", aggregation, ResampleSum, ResampleMean, ResampleMax, ResampleLast)
+}
+
+// aggregation returns the aggregation used for dimension.
+func (o ResampleOptions) aggregation(dimension string) string {
+    if aggregation, exists := o.Dimensions[dimension]; exists {
+        return aggregation
+    }
+    return o.Aggregation
+}
+
+// Resample converts every load series of the workloads to one value per step, so workloads
+// recorded at different resolutions can be compared. Steps are aligned to multiples of the step
+// since the zero time, so every series shares the same step boundaries, and each value is stamped
+// with the start of its step. Steps without values are left out; fill them with FillGaps. The
+// workloads are processed on up to workers goroutines (one per CPU if workers is 0).
+func Resample(workloads []Workload, opts ResampleOptions, workers int) error {
+    if err := opts.Validate(); err != nil {
+        return err
+    }
+    parallelFor(len(workloads), workers, func(i int) {
+        loads := workloads[i].Loads
+        for dimension, load := range loads {
+            loads[dimension] = resampleSeries(load, opts.Step, opts.aggregation(dimension))
+        }
+    })
+    return nil
+}
+
+// resampleSeries combines the values of a series falling in each step with aggregation.
+func resampleSeries(series []TimedValue, step time.Duration, aggregation string) []TimedValue {
+    sorted := append([]TimedValue(nil), series...)
+    sort.SliceStable(sorted, func(i, j int) bool {
+        return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+    })
+
+    var resampled []TimedValue
+    for start := 0; start < len(sorted); {
+        bucket := sorted[start].Timestamp.Truncate(step)
+        end := start + 1
+        for end < len(sorted) && sorted[end].Timestamp.Truncate(step).Equal(bucket) {
+            end++
+        }
+        resampled = append(resampled, TimedValue{Timestamp: bucket, Value: aggregate(sorted[start:end], aggregation)})
+        start = end
+    }
+    return resampled
+}
+
+// aggregate combines the values of one step, which are in time order.
+func aggregate(values []TimedValue, aggregation string) float64 {
+    switch aggregation {
+    case ResampleSum:
+        return sum(values)
+    case ResampleMax:
+        highest := values[0].Value
+        for _, timedValue := range values[1:] {
+            highest = max(highest, timedValue.Value)
+        }
+        return highest
+    case ResampleLast:
+        return values[len(values)-1].Value
+    default:
+        return average(values)
+    }
+}
@@ -0,0 +1,245 @@
+package laplace
+
+import (
+    "fmt"
+    "log"
+    "math"
+    "sort"
+    "time"
+)
+
+// CalculateWorkloadStats calculates various statistics for the workload data, measuring volatility
+// over windows of the given interval and costing each dimension with pricing. The workloads are
+// processed on up to workers goroutines (one per CPU if workers is 0) before their totals are merged.
+// It returns the total load per dimension, the total cost and the total value generated.
+func CalculateWorkloadStats(data *Data, interval time.Duration, pricing Pricing, workers int) (map[string]float64, float64, float64) {
+    // Sum up each workload's loads, costs and volatility in parallel. Errors are logged afterwards
+    // in workload order so the output doesn't depend on scheduling.
+    errs := make([]error, len(data.Workloads))
+    parallelFor(len(data.Workloads), workers, func(i int) {
+        workload := &data.Workloads[i]
+
+        // Calculate and store the total load for each dimension.
+        workload.TotalLoads = make(map[string]float64, len(workload.Loads))
+        for dimension, load := range workload.Loads {
+            workload.TotalLoads[dimension] = sum(load)
+        }
+
+        // Calculate the percentiles of each dimension.
+        workload.Percentiles = calculateLoadPercentiles(*workload)
+
+        // Calculate the cost of each dimension and the total cost for the workload.
+        workload.Costs = calculateCosts(workload, pricing)
+        workload.TotalCost = calculateTotalCost(workload)
+
+        // Calculate volatilities
+        volatilities, err := CalculateRelativeVolatility(*workload, interval)
+        errs[i] = err
+        workload.Volatilities = volatilities
+        workload.CombinedVolatility = calculateCombinedVolatility(*workload, interval)
+        workload.NormalizedVolatilities = make(map[string]NormalizedVolatility, len(workload.Loads))
+        for dimension, load := range workload.Loads {
+            workload.NormalizedVolatilities[dimension] = calculateNormalizedVolatility(timedValues(load), volatilities[dimension])
+        }
+    })
+    for i, err := range errs {
+        if err != nil {
+            log.Printf("Error calculating volatility for workload %s: %v", data.Workloads[i].Name, err)
+        }
+    }
+
+    return calculateRelativeStats(data)
+}
+
+// calculateRelativeStats sets each workload's share of the fleet's load, cost and value from the
+// totals already stored on the workloads, and returns the total load per dimension, the total cost
+// and the total value generated.
+func calculateRelativeStats(data *Data) (map[string]float64, float64, float64) {
+    // Initialize variables to hold cumulative statistics.
+    totalLoads := make(map[string]float64)
+    var totalCost, totalValueGenerated float64
+
+    // Accumulate totals across all workloads.
+    for _, workload := range data.Workloads {
+        for dimension, total := range workload.TotalLoads {
+            totalLoads[dimension] += total
+        }
+        totalCost += workload.TotalCost
+        totalValueGenerated += workload.ValueGenerated
+    }
+
+    // Calculate grand totals for each load dimension.
+    grandTotalLoads := make(map[string]float64)
+    CalculateGrandSums(data, grandTotalLoads)
+
+    // Calculate the relative contributions of each workload.
+    for i := range data.Workloads {
+        calculateRelativeContributions(&data.Workloads[i], grandTotalLoads, totalValueGenerated, totalCost)
+    }
+
+    // Return cumulative statistics.
+    return totalLoads, totalCost, totalValueGenerated
+}
+
+// calculateRelativeContributions sets a workload's share of the fleet's load, cost and value, and
+// its total load across every dimension.
+func calculateRelativeContributions(workload *Workload, grandTotalLoads map[string]float64, totalValueGenerated, totalCost float64) {
+    // Calculate relative loads
+    workload.RelativeLoads = make(map[string]float64, len(workload.TotalLoads))
+    for dimension, total := range workload.TotalLoads {
+        if grandTotalLoads[dimension] > 0 {
+            workload.RelativeLoads[dimension] = (total / grandTotalLoads[dimension]) * 100
+        }
+    }
+    if totalCost > 0 {
+        workload.RelativeCost = (workload.TotalCost / totalCost) * 100
+    }
+
+    // Calculate relative value generated
+    if totalValueGenerated > 0 {
+        workload.RelativeValueGenerated = (workload.ValueGenerated / totalValueGenerated) * 100
+    }
+
+    // Sum the dimensions in sorted order so the total doesn't depend on map iteration.
+    dimensions := make([]string, 0, len(workload.TotalLoads))
+    for dimension := range workload.TotalLoads {
+        dimensions = append(dimensions, dimension)
+    }
+    sort.Strings(dimensions)
+    var totalLoad float64
+    for _, dimension := range dimensions {
+        totalLoad += workload.TotalLoads[dimension]
+    }
+    workload.TotalLoad = totalLoad
+}
+
+// PrintWorkloadStats prints the statistics calculated by CalculateWorkloadStats for each workload.
+func PrintWorkloadStats(data Data) {
+    for _, workload := range data.Workloads {
+        printWorkloadStats(workload)
+    }
+}
+
+// printWorkloadStats prints the statistics of a single workload.
+func printWorkloadStats(workload Workload) {
+    // Take the dimensions from the totals, which a StreamAnalyzer keeps after dropping the loads.
+    dimensions := make([]string, 0, len(workload.TotalLoads))
+    for dimension := range workload.TotalLoads {
+        dimensions = append(dimensions, dimension)
+    }
+    sort.Strings(dimensions)
+    fmt.Printf("Workload: %s\n", workload.Name)
+    for _, dimension := range dimensions {
+        fmt.Printf("  Total Load %s: %.2f\n", dimension, workload.TotalLoads[dimension])
+    }
+    for _, dimension := range dimensions {
+        fmt.Printf("  Relative Load %s: %.2f%%\n", dimension, workload.RelativeLoads[dimension])
+    }
+    for _, dimension := range dimensions {
+        fmt.Printf("  Cost %s: %.2f\n", dimension, workload.Costs[dimension])
+    }
+    printCostBreakdown(workload.CostBreakdown)
+    fmt.Printf("  Total Cost: %.2f\n", workload.TotalCost)
+    fmt.Printf("  Relative Cost: %.2f%%\n", workload.RelativeCost)
+    fmt.Printf("  Relative Value Generated: %.2f%%\n", workload.RelativeValueGenerated)
+    for _, dimension := range dimensions {
+        fmt.Printf("  Volatility Load %s: %.2f\n", dimension, workload.Volatilities[dimension])
+    }
+    for _, dimension := range dimensions {
+        n := workload.NormalizedVolatilities[dimension]
+        fmt.Printf("  Normalized Volatility Load %s: CV %.2f, Peak/Mean %.2f, IQR/Mean %.2f\n", dimension, n.CV, n.PeakToMean, n.IQRToMean)
+    }
+    for _, dimension := range dimensions {
+        if synthesized := workload.SynthesizedPoints[dimension]; synthesized > 0 {
+            fmt.Printf("  Synthesized Points %s: %d\n", dimension, synthesized)
+        }
+    }
+    for _, dimension := range dimensions {
+        p := workload.Percentiles[dimension]
+        fmt.Printf("  Percentiles Load %s: P50 %.2f, P95 %.2f, P99 %.2f\n", dimension, p.P50, p.P95, p.P99)
+    }
+    // Add two empty lines for separation
+    fmt.Println()
+    fmt.Println()
+}
+
+// printCostBreakdown prints the cost charged in each volume tier and in each time window. Flat-priced
+// costs in no window are left out, since they are already covered by the per-dimension cost lines.
+func printCostBreakdown(breakdown map[CostBucket]float64) {
+    byTier := make(map[string]float64)
+    byWindow := make(map[string]float64)
+    var tiers, windows []string
+    for _, bucket := range SortedCostBuckets(breakdown) {
+        cost := breakdown[bucket]
+        if bucket.Tier != baseTier {
+            tier := bucket.Dimension + " " + bucket.Tier
+            if _, seen := byTier[tier]; !seen {
+                tiers = append(tiers, tier)
+            }
+            byTier[tier] += cost
+        }
+        if bucket.Window != standardWindow {
+            window := bucket.Dimension + " " + bucket.Window
+            if _, seen := byWindow[window]; !seen {
+                windows = append(windows, window)
+            }
+            byWindow[window] += cost
+        }
+    }
+    for _, tier := range tiers {
+        fmt.Printf("  Cost Tier %s: %.2f\n", tier, byTier[tier])
+    }
+    for _, window := range windows {
+        fmt.Printf("  Cost Window %s: %.2f\n", window, byWindow[window])
+    }
+}
+
+//We slice the array up like a pizza
+func sum(timedValues []TimedValue) float64 {
+    var total float64
+    for _, timedValue := range timedValues {
+        total += timedValue.Value
+    }
+    return total
+}
+
+//We sum all the loads in the individual workloads togethor to get a sum that we can then use to get relative value
+func CalculateGrandSums(data *Data, grandTotalLoads map[string]float64) {
+    for _, workload := range data.Workloads {
+        for dimension, total := range workload.TotalLoads {
+            grandTotalLoads[dimension] += total
+        }
+    }
+}
+
+func average(load []TimedValue) float64 {
+    if len(load) == 0 {
+        return 0
+    }
+
+    sum := 0.0
+    for _, val := range load {
+        sum += val.Value
+    }
+    return sum / float64(len(load))
+}
+
+func calculateStandardDeviation(values []float64) float64 {
+    if len(values) == 0 {
+        return 0
+    }
+
+    mean := 0.0
+    for _, v := range values {
+        mean += v
+    }
+    mean /= float64(len(values))
+
+    variance := 0.0
+    for _, v := range values {
+        variance += (v - mean) * (v - mean)
+    }
+    variance /= float64(len(values))
+
+    return math.Sqrt(variance)
+}
@@ -0,0 +1,503 @@
+package laplace
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "math"
+    "os"
+    "sort"
+    "strings"
+    "time"
+)
+
+// workloadVisitor receives the fields of each workload as streamWorkloads decodes them.
+type workloadVisitor interface {
+    beginWorkload()
+    setName(name string)
+    setValueGenerated(value float64)
+    addValue(dimension string, timedValue TimedValue)
+    endWorkload() error
+}
+
+// legacyDimensions are the load arrays older files declare next to the workload name.
+var legacyDimensions = []string{"load1", "load2", "load3"}
+
+// streamWorkloads decodes data in the {"workloads": [...]} layout from r token by token, handing
+// each load value to visitor as soon as it is read, so no workload is ever held in memory whole.
+// Keys are matched case-insensitively, as encoding/json does, and unknown keys are skipped.
+func streamWorkloads(r io.Reader, visitor workloadVisitor) error {
+    decoder := json.NewDecoder(r)
+    if err := expectDelim(decoder, '{'); err != nil {
+        return err
+    }
+    for decoder.More() {
+        key, err := objectKey(decoder)
+        if err != nil {
+            return err
+        }
+        if !strings.EqualFold(key, "workloads") {
+            if err := skipValue(decoder); err != nil {
+                return err
+            }
+            continue
+        }
+        if err := streamWorkloadArray(decoder, visitor); err != nil {
+            return err
+        }
+    }
+    return expectDelim(decoder, '}')
+}
+
+func streamWorkloadArray(decoder *json.Decoder, visitor workloadVisitor) error {
+    if isNull, err := openValue(decoder, '['); err != nil || isNull {
+        return err
+    }
+    for decoder.More() {
+        if err := streamWorkload(decoder, visitor); err != nil {
+            return err
+        }
+    }
+    return expectDelim(decoder, ']')
+}
+
+func streamWorkload(decoder *json.Decoder, visitor workloadVisitor) error {
+    visitor.beginWorkload()
+    isNull, err := openValue(decoder, '{')
+    if err != nil {
+        return err
+    }
+    if isNull {
+        return visitor.endWorkload()
+    }
+
+    for decoder.More() {
+        key, err := objectKey(decoder)
+        if err != nil {
+            return err
+        }
+        switch {
+        case strings.EqualFold(key, "name"):
+            var name string
+            if err := decoder.Decode(&name); err != nil {
+                return fmt.Errorf("workload name: %w", err)
+            }
+            visitor.setName(name)
+        case strings.EqualFold(key, "valueGenerated"):
+            var value float64
+            if err := decoder.Decode(&value); err != nil {
+                return fmt.Errorf("workload valueGenerated: %w", err)
+            }
+            visitor.setValueGenerated(value)
+        case strings.EqualFold(key, "loads"):
+            err = streamLoads(decoder, visitor)
+        case isLegacyDimension(key):
+            err = streamSeries(decoder, strings.ToLower(key), visitor)
+        default:
+            err = skipValue(decoder)
+        }
+        if err != nil {
+            return err
+        }
+    }
+    if err := expectDelim(decoder, '}'); err != nil {
+        return err
+    }
+    return visitor.endWorkload()
+}
+
+func streamLoads(decoder *json.Decoder, visitor workloadVisitor) error {
+    if isNull, err := openValue(decoder, '{'); err != nil || isNull {
+        return err
+    }
+    for decoder.More() {
+        dimension, err := objectKey(decoder)
+        if err != nil {
+            return err
+        }
+        if err := CheckDimension(dimension); err != nil {
+            return err
+        }
+        if err := streamSeries(decoder, dimension, visitor); err != nil {
+            return err
+        }
+    }
+    return expectDelim(decoder, '}')
+}
+
+// streamSeries decodes one TimedValue array an element at a time.
+func streamSeries(decoder *json.Decoder, dimension string, visitor workloadVisitor) error {
+    if isNull, err := openValue(decoder, '['); err != nil || isNull {
+        return err
+    }
+    for decoder.More() {
+        var timedValue TimedValue
+        if err := decoder.Decode(&timedValue); err != nil {
+            return fmt.Errorf("load %s: %w", dimension, err)
+        }
+        visitor.addValue(dimension, timedValue)
+    }
+    return expectDelim(decoder, ']')
+}
+
+func isLegacyDimension(key string) bool {
+    for _, dimension := range legacyDimensions {
+        if strings.EqualFold(key, dimension) {
+            return true
+        }
+    }
+    return false
+}
+
+// openValue reads the opening delimiter of an object or array. It reports true, without error,
+// when the value is null instead.
+func openValue(decoder *json.Decoder, delim json.Delim) (bool, error) {
+    token, err := decoder.Token()
+    if err != nil {
+        return false, err
+    }
+    if token == nil {
+        return true, nil
+    }
+    if token != delim {
+        return false, fmt.Errorf("expected %v, got %v", delim, token)
+    }
+    return false, nil
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+    isNull, err := openValue(decoder, delim)
+    if err == nil && isNull {
+        err = fmt.Errorf("expected %v, got null", delim)
+    }
+    return err
+}
+
+func objectKey(decoder *json.Decoder) (string, error) {
+    token, err := decoder.Token()
+    if err != nil {
+        return "", err
+    }
+    key, ok := token.(string)
+    if !ok {
+        return "", fmt.Errorf("expected object key, got %v", token)
+    }
+    return key, nil
+}
+
+// skipValue discards the next value, however deeply nested, without buffering it.
+func skipValue(decoder *json.Decoder) error {
+    depth := 0
+    for {
+        token, err := decoder.Token()
+        if err != nil {
+            return err
+        }
+        switch token {
+        case json.Delim('{'), json.Delim('['):
+            depth++
+        case json.Delim('}'), json.Delim(']'):
+            depth--
+        }
+        if depth == 0 {
+            return nil
+        }
+    }
+}
+
+// StreamAnalyzer calculates the statistics of CalculateWorkloadStats and the summed series of
+// AggregateWorkloads while workload files are read, keeping only the loads of the workload being
+// decoded and one total per timestamp. Memory therefore grows with the length of the series, not
+// with the number of workloads.
+//
+// Unlike CalculateWorkloadStats, gaps can't be filled with FillGaps or series resampled with
+// Resample, since a workload's loads are never held whole: a timestamp missing from a dimension
+// contributes no load, as with GapMissing.
+type StreamAnalyzer struct {
+    // IntervalChanges, when set, receives the interval changes of each workload's combined load
+    // as soon as the workload has been read.
+    IntervalChanges *IntervalChangeWriter
+
+    interval  time.Duration
+    pricing   Pricing
+    workloads []Workload
+    summed    map[int64]SummedWorkload // by UnixNano, so one instant in two time zones is one total
+    current   *workloadMeter
+}
+
+// StreamResult holds what a StreamAnalyzer calculated over every workload it consumed.
+type StreamResult struct {
+    // Data holds the workloads with their derived statistics but without their loads.
+    Data                *Data
+    Summed              []SummedWorkload
+    Peak                PeakUsage
+    TotalLoads          map[string]float64
+    TotalCost           float64
+    TotalValueGenerated float64
+}
+
+// workloadMeter accumulates the statistics of the workload being decoded.
+type workloadMeter struct {
+    workload   Workload
+    volatility map[string]*intervalMeter
+    series     map[string][]TimedValue // priced once the workload ends, in timestamp order
+    loads      map[int64]*timedTotals // by UnixNano
+}
+
+// timedTotals is the load of each dimension at one timestamp.
+type timedTotals struct {
+    timestamp time.Time
+    totals    map[string]float64
+}
+
+// NewStreamAnalyzer returns an analyzer measuring volatility over windows of the given interval
+// and costing each dimension with pricing.
+func NewStreamAnalyzer(interval time.Duration, pricing Pricing) *StreamAnalyzer {
+    return &StreamAnalyzer{
+        interval: interval,
+        pricing:  pricing,
+        summed:   make(map[int64]SummedWorkload),
+    }
+}
+
+// ConsumeFile streams the workloads of a JSON file into the analyzer.
+func (a *StreamAnalyzer) ConsumeFile(filename string) error {
+    file, err := os.Open(filename)
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+
+    return a.Consume(file)
+}
+
+// Consume streams workloads in the {"workloads": [...]} layout read by LoadData from r. When
+// decoding fails, the workloads read in full before the error are kept and the rest are dropped.
+func (a *StreamAnalyzer) Consume(r io.Reader) error {
+    err := streamWorkloads(r, a)
+    a.current = nil
+    return err
+}
+
+func (a *StreamAnalyzer) beginWorkload() {
+    a.current = &workloadMeter{
+        workload: Workload{
+            TotalLoads:             make(map[string]float64),
+            Costs:                  make(map[string]float64),
+            CostBreakdown:          make(map[CostBucket]float64),
+            Volatilities:           make(map[string]float64),
+            NormalizedVolatilities: make(map[string]NormalizedVolatility),
+            Percentiles:            make(map[string]Percentiles),
+        },
+        volatility: make(map[string]*intervalMeter),
+        series:     make(map[string][]TimedValue),
+        loads:      make(map[int64]*timedTotals),
+    }
+}
+
+func (a *StreamAnalyzer) setName(name string) {
+    a.current.workload.Name = name
+}
+
+func (a *StreamAnalyzer) setValueGenerated(value float64) {
+    a.current.workload.ValueGenerated = value
+}
+
+func (a *StreamAnalyzer) addValue(dimension string, timedValue TimedValue) {
+    meter := a.current
+    if _, exists := meter.volatility[dimension]; !exists {
+        meter.volatility[dimension] = &intervalMeter{interval: a.interval}
+    }
+    meter.workload.TotalLoads[dimension] += timedValue.Value
+    meter.volatility[dimension].add(timedValue)
+    meter.series[dimension] = append(meter.series[dimension], timedValue)
+
+    key := timedValue.Timestamp.UnixNano()
+    loads, exists := meter.loads[key]
+    if !exists {
+        loads = &timedTotals{timestamp: timedValue.Timestamp, totals: make(map[string]float64)}
+        meter.loads[key] = loads
+    }
+    loads.totals[dimension] += timedValue.Value
+}
+
+// endWorkload finishes the statistics of the workload being decoded and adds its loads to the
+// summed series.
+func (a *StreamAnalyzer) endWorkload() error {
+    meter := a.current
+    a.current = nil
+    workload := meter.workload
+
+    for dimension, series := range meter.series {
+        values := timedValues(series)
+        workload.Costs[dimension] = a.pricing.priceLoad(dimension, series, workload.CostBreakdown)
+        workload.Volatilities[dimension] = meter.volatility[dimension].volatility()
+        workload.Percentiles[dimension] = calculatePercentiles(values)
+        workload.NormalizedVolatilities[dimension] = calculateNormalizedVolatility(values, workload.Volatilities[dimension])
+    }
+    workload.TotalCost = calculateTotalCost(&workload)
+
+    combined := make([]TimedValue, 0, len(meter.loads))
+    for key, loads := range meter.loads {
+        var total float64
+        summedWorkload, exists := a.summed[key]
+        if !exists {
+            summedWorkload = SummedWorkload{Timestamp: loads.timestamp, Totals: make(map[string]float64)}
+            a.summed[key] = summedWorkload
+        }
+        for dimension, value := range loads.totals {
+            summedWorkload.Totals[dimension] += value
+            total += value
+        }
+        combined = append(combined, TimedValue{Timestamp: loads.timestamp, Value: total})
+    }
+    sort.Slice(combined, func(i, j int) bool {
+        return combined[i].Timestamp.Before(combined[j].Timestamp)
+    })
+
+    combinedVolatility := &intervalMeter{interval: a.interval}
+    for _, timedValue := range combined {
+        combinedVolatility.add(timedValue)
+    }
+    workload.CombinedVolatility = combinedVolatility.volatility()
+
+    a.workloads = append(a.workloads, workload)
+    if a.IntervalChanges != nil {
+        return a.IntervalChanges.write(workload.Name, combined)
+    }
+    return nil
+}
+
+// Finish calculates each workload's share of the fleet's load, cost and value, and returns the
+// statistics of every workload consumed so far.
+func (a *StreamAnalyzer) Finish() *StreamResult {
+    data := &Data{Workloads: a.workloads}
+    totalLoads, totalCost, totalValueGenerated := calculateRelativeStats(data)
+    summed := sortSummedWorkloads(a.summed)
+
+    return &StreamResult{
+        Data:                data,
+        Summed:              summed,
+        Peak:                findSummedPeak(summed),
+        TotalLoads:          totalLoads,
+        TotalCost:           totalCost,
+        TotalValueGenerated: totalValueGenerated,
+    }
+}
+
+// intervalMeter calculates the standard deviation of a series' interval averages, as
+// calculateIntervalAverages and calculateStandardDeviation do, one value at a time. Windows follow
+// groupByInterval, so values must be added in timestamp order.
+type intervalMeter struct {
+    interval    time.Duration
+    windowStart time.Time
+    started     bool
+    sum         float64
+    count       int
+
+    // Running mean and sum of squared deviations of the closed windows' averages.
+    windows int
+    mean    float64
+    squares float64
+}
+
+func (m *intervalMeter) add(timedValue TimedValue) {
+    if !m.started {
+        m.windowStart = timedValue.Timestamp
+        m.started = true
+    } else if elapsed := timedValue.Timestamp.Sub(m.windowStart); elapsed >= m.interval {
+        m.closeWindow()
+        m.windowStart = m.windowStart.Add(elapsed / m.interval * m.interval)
+    }
+    m.sum += timedValue.Value
+    m.count++
+}
+
+func (m *intervalMeter) closeWindow() {
+    if m.count == 0 {
+        return
+    }
+    average := m.sum / float64(m.count)
+    m.windows++
+    delta := average - m.mean
+    m.mean += delta / float64(m.windows)
+    m.squares += delta * (average - m.mean)
+    m.sum, m.count = 0, 0
+}
+
+// volatility closes the current window and returns the standard deviation of the window averages.
+func (m *intervalMeter) volatility() float64 {
+    m.closeWindow()
+    if m.windows == 0 {
+        return 0
+    }
+    return math.Sqrt(m.squares / float64(m.windows))
+}
+
+// contributionCollector records each workload's load at a set of peaks.
+type contributionCollector struct {
+    // peaks maps a timestamp, in Unix nanoseconds, to the indexes of the peaks at it.
+    peaks         map[int64][]int
+    dimensions    []string
+    name          string
+    loads         []float64
+    matched       map[contributionMatch]bool
+    contributions [][]WorkloadContribution
+}
+
+// contributionMatch identifies the series of one dimension counted towards one peak.
+type contributionMatch struct {
+    peak      int
+    dimension string
+}
+
+func (c *contributionCollector) beginWorkload() {
+    c.name = ""
+    c.loads = make([]float64, len(c.dimensions))
+    c.matched = make(map[contributionMatch]bool)
+}
+
+func (c *contributionCollector) setName(name string) {
+    c.name = name
+}
+
+func (c *contributionCollector) setValueGenerated(float64) {}
+
+// addValue counts the first value of each series at a peak's timestamp, as SeriesIndex does.
+func (c *contributionCollector) addValue(dimension string, timedValue TimedValue) {
+    for _, i := range c.peaks[timedValue.Timestamp.UnixNano()] {
+        if c.dimensions[i] != TotalDimension && c.dimensions[i] != dimension {
+            continue
+        }
+        match := contributionMatch{peak: i, dimension: dimension}
+        if !c.matched[match] {
+            c.matched[match] = true
+            c.loads[i] += timedValue.Value
+        }
+    }
+}
+
+func (c *contributionCollector) endWorkload() error {
+    for i, load := range c.loads {
+        c.contributions[i] = append(c.contributions[i], WorkloadContribution{Name: c.name, LoadAtPeak: load})
+    }
+    return nil
+}
+
+// StreamContributionsAt streams the workloads from r and returns, for each peak, every workload's
+// load in the peak's dimension at the peak's timestamp, summed across its dimensions for a
+// TotalDimension peak. The peaks are typically found in the summed series of a StreamAnalyzer.
+func StreamContributionsAt(r io.Reader, peaks []PeakUsage) ([][]WorkloadContribution, error) {
+    collector := &contributionCollector{
+        peaks:         make(map[int64][]int),
+        dimensions:    make([]string, len(peaks)),
+        contributions: make([][]WorkloadContribution, len(peaks)),
+    }
+    for i, peak := range peaks {
+        timestamp := peak.Timestamp.UnixNano()
+        collector.peaks[timestamp] = append(collector.peaks[timestamp], i)
+        collector.dimensions[i] = peak.Dimension
+    }
+    err := streamWorkloads(r, collector)
+    return collector.contributions, err
+}
@@ -0,0 +1,298 @@
+package laplace
+
+import (
+    "fmt"
+    "math"
+    "sort"
+)
+
+// Tiering modes supported by TierValues.
+const (
+    // TierThresholds places values at or above each of a fixed list of thresholds in the next tier up.
+    TierThresholds = "thresholds"
+    // TierQuantiles places values above each quantile of the values in the next tier up.
+    TierQuantiles = "quantiles"
+    // TierKMeans clusters the values around the given number of means.
+    TierKMeans = "kmeans"
+    // TierJenks splits the sorted values into the given number of classes with the least variance
+    // within each class (Jenks natural breaks).
+    TierJenks = "jenks"
+)
+
+// maxKMeansIterations bounds the refinement of the k-means clusters.
+const maxKMeansIterations = 100
+
+// TierOptions configures TierValues. Thresholds are the ascending boundaries of TierThresholds and
+// Quantiles the ascending cut points, between 0 and 1, of TierQuantiles; either gives one more tier
+// than it has entries. Tiers is the number of tiers of TierKMeans and TierJenks. Labels names the
+// tiers lowest first and defaults to Low, Medium and High for three tiers and Tier 1, Tier 2, ...
+// otherwise.
+type TierOptions struct {
+    Mode       string
+    Thresholds []float64
+    Quantiles  []float64
+    Tiers      int
+    Labels     []string
+}
+
+// DefaultTierOptions splits the values into Low, Medium and High thirds.
+func DefaultTierOptions() TierOptions {
+    return TierOptions{Mode: TierQuantiles, Quantiles: []float64{1.0 / 3, 2.0 / 3}, Tiers: 3}
+}
+
+// TierCount returns the number of tiers the options produce.
+func (o TierOptions) TierCount() int {
+    switch o.Mode {
+    case TierThresholds:
+        return len(o.Thresholds) + 1
+    case TierQuantiles:
+        return len(o.Quantiles) + 1
+    default:
+        return o.Tiers
+    }
+}
+
+// TierLabels returns the name of each tier, lowest first.
+func (o TierOptions) TierLabels() []string {
+    if len(o.Labels) > 0 {
+        return o.Labels
+    }
+    count := o.TierCount()
+    if count == 3 {
+        return []string{"Low", "Medium", "High"}
+    }
+    labels := make([]string, count)
+    for i := range labels {
+        labels[i] = fmt.Sprintf("Tier %d", i+1)
+    }
+    return labels
+}
+
+// Validate checks the options.
+func (o TierOptions) Validate() error {
+    switch o.Mode {
+    case TierThresholds:
+        if err := validateAscending("threshold", o.Thresholds); err != nil {
+            return err
+        }
+    case TierQuantiles:
+        if err := validateAscending("quantile", o.Quantiles); err != nil {
+            return err
+        }
+        for _, quantile := range o.Quantiles {
+            if quantile <= 0 || quantile >= 1 {
+                return fmt.Errorf("quantiles must be between 0 and 1, got %v", quantile)
+            }
+        }
+    case TierKMeans, TierJenks:
+        if o.Tiers < 1 {
+            return fmt.Errorf("%s needs at least 1 tier, got %d", o.Mode, o.Tiers)
+        }
+    default:
+        return fmt.Errorf("unknown tiering mode %q: want %s, %s, %s or %s", o.Mode, TierThresholds, TierQuantiles, TierKMeans, TierJenks)
+    }
+    if len(o.Labels) > 0 && len(o.Labels) != o.TierCount() {
+        return fmt.Errorf("%d tier labels given for %d tiers", len(o.Labels), o.TierCount())
+    }
+    return nil
+}
+
+// validateAscending checks that a list of boundaries is non-empty and strictly ascending.
+func validateAscending(name string, boundaries []float64) error {
+    if len(boundaries) == 0 {
+        return fmt.Errorf("at least one %s is required", name)
+    }
+    for i := 1; i < len(boundaries); i++ {
+        if boundaries[i] <= boundaries[i-1] {
+            return fmt.Errorf("%ss must be in ascending order", name)
+        }
+    }
+    return nil
+}
+
+// TierValues assigns each value a tier, from 0 for the lowest to TierCount()-1. Equal values
+// always share a tier, so a fleet of identical values lands entirely in the lowest tier instead of
+// being split.
+func TierValues(values []float64, opts TierOptions) ([]int, error) {
+    if err := opts.Validate(); err != nil {
+        return nil, err
+    }
+    tiers := make([]int, len(values))
+    if len(values) == 0 {
+        return tiers, nil
+    }
+    sorted := append([]float64(nil), values...)
+    sort.Float64s(sorted)
+
+    switch opts.Mode {
+    case TierThresholds:
+        for i, value := range values {
+            tiers[i] = sort.Search(len(opts.Thresholds), func(j int) bool { return opts.Thresholds[j] > value })
+        }
+    case TierQuantiles:
+        bounds := make([]float64, len(opts.Quantiles))
+        for i, quantile := range opts.Quantiles {
+            bounds[i] = sorted[quantileIndex(quantile, len(sorted))]
+        }
+        for i, value := range values {
+            tiers[i] = sort.Search(len(bounds), func(j int) bool { return bounds[j] >= value })
+        }
+    case TierKMeans:
+        centroids := kMeans(sorted, opts.Tiers)
+        for i, value := range values {
+            tiers[i] = nearestCentroid(centroids, value)
+        }
+    case TierJenks:
+        maxima := jenksBreaks(sorted, opts.Tiers)
+        for i, value := range values {
+            tiers[i] = sort.Search(len(maxima), func(j int) bool { return maxima[j] >= value })
+        }
+    }
+    return tiers, nil
+}
+
+// quantileIndex returns the nearest-rank index of the q quantile of n sorted values. The rank is
+// rounded down when q*n is within rounding error of an integer, so cut points such as 1/3 split
+// evenly divisible counts exactly.
+func quantileIndex(q float64, n int) int {
+    rank := int(math.Ceil(q*float64(n) - 1e-9))
+    if rank < 1 {
+        return 0
+    }
+    if rank > n {
+        return n - 1
+    }
+    return rank - 1
+}
+
+// kMeans clusters the sorted values around up to k means, one per distinct value at most, and
+// returns the means in ascending order. The means start at evenly spaced ranks so the result
+// doesn't depend on a random seed.
+func kMeans(sorted []float64, k int) []float64 {
+    distinct := 1
+    for i := 1; i < len(sorted); i++ {
+        if sorted[i] != sorted[i-1] {
+            distinct++
+        }
+    }
+    if k > distinct {
+        k = distinct
+    }
+
+    centroids := make([]float64, k)
+    for i := range centroids {
+        centroids[i] = sorted[(2*i+1)*len(sorted)/(2*k)]
+    }
+    assignments := make([]int, len(sorted))
+    for iteration := 0; iteration < maxKMeansIterations; iteration++ {
+        changed := iteration == 0
+        sums := make([]float64, k)
+        counts := make([]int, k)
+        for i, value := range sorted {
+            nearest := nearestCentroid(centroids, value)
+            if nearest != assignments[i] {
+                assignments[i] = nearest
+                changed = true
+            }
+            sums[nearest] += value
+            counts[nearest]++
+        }
+        if !changed {
+            break
+        }
+        for i := range centroids {
+            if counts[i] > 0 {
+                centroids[i] = sums[i] / float64(counts[i])
+            }
+        }
+    }
+    sort.Float64s(centroids)
+    return centroids
+}
+
+// nearestCentroid returns the index of the centroid closest to value, the lower one on a tie.
+func nearestCentroid(centroids []float64, value float64) int {
+    nearest := 0
+    for i := 1; i < len(centroids); i++ {
+        if math.Abs(value-centroids[i]) < math.Abs(value-centroids[nearest]) {
+            nearest = i
+        }
+    }
+    return nearest
+}
+
+// jenksBreaks splits the sorted values into up to k consecutive classes minimizing the total sum
+// of squared deviations from each class mean, and returns the largest value of each class.
+func jenksBreaks(sorted []float64, k int) []float64 {
+    n := len(sorted)
+    if k > n {
+        k = n
+    }
+
+    // Prefix sums give the squared deviation of any run of values in constant time.
+    sums := make([]float64, n+1)
+    squares := make([]float64, n+1)
+    for i, value := range sorted {
+        sums[i+1] = sums[i] + value
+        squares[i+1] = squares[i] + value*value
+    }
+    deviation := func(from, to int) float64 { // values[from:to]
+        count := float64(to - from)
+        total := sums[to] - sums[from]
+        return squares[to] - squares[from] - total*total/count
+    }
+
+    // cost[c][j] is the least deviation of the first j values split into c+1 classes, and
+    // start[c][j] the index the last of those classes starts at.
+    cost := make([][]float64, k)
+    start := make([][]int, k)
+    for c := range cost {
+        cost[c] = make([]float64, n+1)
+        start[c] = make([]int, n+1)
+        for j := range cost[c] {
+            cost[c][j] = math.Inf(1)
+        }
+    }
+    for j := 1; j <= n; j++ {
+        cost[0][j] = deviation(0, j)
+    }
+    for c := 1; c < k; c++ {
+        for j := c + 1; j <= n; j++ {
+            for i := c; i < j; i++ {
+                if total := cost[c-1][i] + deviation(i, j); total < cost[c][j] {
+                    cost[c][j] = total
+                    start[c][j] = i
+                }
+            }
+        }
+    }
+
+    maxima := make([]float64, k)
+    end := n
+    for c := k - 1; c >= 0; c-- {
+        maxima[c] = sorted[end-1]
+        end = start[c][end]
+    }
+    return maxima
+}
+
+// AssignVolatilityTiers sets each workload's VolatilityTier from its average across dimensions of
+// the given volatility metric, as calculated by CalculateWorkloadStats.
+func AssignVolatilityTiers(workloads []Workload, metric string, opts TierOptions) error {
+    if err := ValidateVolatilityMetric(metric); err != nil {
+        return err
+    }
+    values := make([]float64, len(workloads))
+    for i, workload := range workloads {
+        values[i] = WorkloadVolatilityMetric(workload, metric)
+    }
+    tiers, err := TierValues(values, opts)
+    if err != nil {
+        return err
+    }
+    labels := opts.TierLabels()
+    for i := range workloads {
+        workloads[i].VolatilityTier = labels[tiers[i]]
+    }
+    return nil
+}
@@ -0,0 +1,592 @@
+package laplace
+
+import (
+    "bufio"
+    "encoding/csv"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "math"
+    "os"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// Checks reported by ValidateFiles.
+const (
+    CheckDecode                = "decode"
+    CheckDuplicateName         = "duplicate-name"
+    CheckUnsortedTimestamps    = "unsorted-timestamps"
+    CheckDuplicateTimestamps   = "duplicate-timestamps"
+    CheckBadTimestamp          = "bad-timestamp"
+    CheckLengthMismatch        = "length-mismatch"
+    CheckNaNValue              = "nan-value"
+    CheckNegativeValue         = "negative-value"
+    CheckMissingValueGenerated = "missing-value-generated"
+    CheckTimezone              = "timezone-mismatch"
+    CheckReservedDimension     = "reserved-dimension"
+)
+
+// ValidationIssue is one problem found in a workload file. Problems found at several points of a
+// load series are reported once, with the number of points in Count and the first point's
+// timestamp in Timestamp.
+type ValidationIssue struct {
+    File      string `json:"file"`
+    Workload  string `json:"workload,omitempty"`
+    Dimension string `json:"dimension,omitempty"`
+    Check     string `json:"check"`
+    Count     int    `json:"count"`
+    Timestamp string `json:"timestamp,omitempty"`
+    Message   string `json:"message"`
+}
+
+// ValidationReport lists the problems found in a set of workload files.
+type ValidationReport struct {
+    Files     int               `json:"files"`
+    Workloads int               `json:"workloads"`
+    Issues    []ValidationIssue `json:"issues"`
+}
+
+// ValidationOptions configures ValidateFiles. Format is the input format of the files, or empty to
+// go by their extensions (see FileFormat). AllowNegative accepts negative load values, such as the
+// deltas of a counter or the volatile series written by generate.
+type ValidationOptions struct {
+    Format        string
+    AllowNegative bool
+}
+
+// rawWorkload is a workload as written in a file, keeping what decoding into Workload loses: whether
+// valueGenerated was given and load values that aren't numbers. optionalValueGenerated is set for
+// workloads read from formats that may leave valueGenerated out.
+type rawWorkload struct {
+    Name                   string                     `json:"name"`
+    ValueGenerated         *float64                   `json:"valueGenerated"`
+    Loads                  map[string][]rawTimedValue `json:"loads"`
+    Load1                  []rawTimedValue            `json:"load1"`
+    Load2                  []rawTimedValue            `json:"load2"`
+    Load3                  []rawTimedValue            `json:"load3"`
+    optionalValueGenerated bool
+}
+
+type rawTimedValue struct {
+    Timestamp string          `json:"timestamp"`
+    Value     json.RawMessage `json:"value"`
+}
+
+// dimensions returns the workload's load series by dimension, with the legacy load1, load2 and
+// load3 arrays as dimensions of the same name unless the loads map already has them.
+func (w rawWorkload) dimensions() map[string][]rawTimedValue {
+    loads := make(map[string][]rawTimedValue, len(w.Loads)+3)
+    for dimension, load := range w.Loads {
+        loads[dimension] = load
+    }
+    legacy := map[string][]rawTimedValue{"load1": w.Load1, "load2": w.Load2, "load3": w.Load3}
+    for dimension, load := range legacy {
+        if _, exists := loads[dimension]; load != nil && !exists {
+            loads[dimension] = load
+        }
+    }
+    return loads
+}
+
+// validatedFile is what ValidateFiles learned from one file.
+type validatedFile struct {
+    workloads []rawWorkload
+    issues    []ValidationIssue
+    offsets   []timestampOffsets // by workload
+}
+
+// timestampOffsets are the UTC offsets, in seconds, of a workload's timestamps: the offset of the
+// first timestamp validated and every offset used, sorted.
+type timestampOffsets struct {
+    first int
+    used  []int
+}
+
+// ValidateFiles checks every workload file for duplicate workload names, unsorted, duplicate or
+// malformed timestamps, load dimensions of different lengths, missing (null or "NaN") and negative
+// values, missing valueGenerated, dimensions named TotalDimension and timestamps in more than one
+// time zone. The files are checked on up to workers goroutines (one per CPU if workers is 0) and
+// the issues reported in file order.
+func ValidateFiles(files []string, opts ValidationOptions, workers int) ValidationReport {
+    validated := make([]validatedFile, len(files))
+    parallelFor(len(files), workers, func(i int) {
+        validated[i] = validateFile(files[i], opts)
+    })
+
+    report := ValidationReport{Files: len(files), Issues: []ValidationIssue{}}
+    firstFile := make(map[string]string)
+    referenceOffset, haveReference := 0, false
+    for i, file := range validated {
+        report.Issues = append(report.Issues, file.issues...)
+        for j, workload := range file.workloads {
+            report.Workloads++
+            if first, exists := firstFile[workload.Name]; exists {
+                report.Issues = append(report.Issues, ValidationIssue{
+                    File:     files[i],
+                    Workload: workload.Name,
+                    Check:    CheckDuplicateName,
+                    Count:    1,
+                    Message:  fmt.Sprintf("workload %q is also defined in %s", workload.Name, first),
+                })
+            } else {
+                firstFile[workload.Name] = files[i]
+            }
+
+            // Every timestamp should use the offset of the first timestamp validated.
+            offsets := file.offsets[j].used
+            if len(offsets) == 0 {
+                continue
+            }
+            if !haveReference {
+                referenceOffset, haveReference = file.offsets[j].first, true
+            }
+            if len(offsets) > 1 || offsets[0] != referenceOffset {
+                names := make([]string, len(offsets))
+                for k, offset := range offsets {
+                    names[k] = formatOffset(offset)
+                }
+                report.Issues = append(report.Issues, ValidationIssue{
+                    File:     files[i],
+                    Workload: workload.Name,
+                    Check:    CheckTimezone,
+                    Count:    len(offsets),
+                    Message:  fmt.Sprintf("timestamps use UTC offsets %s, but the first timestamp validated uses %s", strings.Join(names, ", "), formatOffset(referenceOffset)),
+                })
+            }
+        }
+    }
+    return report
+}
+
+// validateFile decodes one file and checks each of its workloads on its own.
+func validateFile(filename string, opts ValidationOptions) validatedFile {
+    workloads, rowIssues, err := decodeRawWorkloads(filename, opts.Format)
+    if err != nil {
+        return validatedFile{issues: []ValidationIssue{{File: filename, Check: CheckDecode, Count: 1, Message: err.Error()}}}
+    }
+
+    file := validatedFile{workloads: workloads, offsets: make([]timestampOffsets, len(workloads))}
+    for _, issue := range rowIssues {
+        issue.File = filename
+        file.issues = append(file.issues, issue)
+    }
+    for i, workload := range workloads {
+        issues, offsets := validateWorkload(filename, workload, opts)
+        file.issues = append(file.issues, issues...)
+        file.offsets[i] = offsets
+    }
+    return file
+}
+
+// decodeRawWorkloads decodes the workloads of a file as written. Long-format CSV and JSON Lines are
+// read a row at a time, reporting each row that can't be read as an issue of its own and checking
+// the rest, and only need a valueGenerated when they have a column or field for it. Prometheus
+// formats, which never carry one, are read with their readers.
+func decodeRawWorkloads(filename, format string) ([]rawWorkload, []ValidationIssue, error) {
+    format = FileFormat(filename, format)
+    if format == FormatCSV || format == FormatJSONL {
+        file, err := os.Open(filename)
+        if err != nil {
+            return nil, nil, err
+        }
+        defer file.Close()
+
+        decode := decodeRawCSV
+        if format == FormatJSONL {
+            decode = decodeRawJSONL
+        }
+        return decode(file)
+    }
+    if format == FormatJSON {
+        contents, err := os.ReadFile(filename)
+        if err != nil {
+            return nil, nil, err
+        }
+        var raw struct {
+            Workloads []rawWorkload `json:"workloads"`
+        }
+        if err := json.Unmarshal(contents, &raw); err != nil {
+            return nil, nil, err
+        }
+        return raw.Workloads, nil, nil
+    }
+
+    data, err := LoadFile(filename, format)
+    if err != nil {
+        return nil, nil, err
+    }
+    workloads := make([]rawWorkload, len(data.Workloads))
+    for i, workload := range data.Workloads {
+        workloads[i] = rawFromWorkload(workload)
+    }
+    return workloads, nil, nil
+}
+
+// decodeRawCSV decodes long-format CSV as written.
+func decodeRawCSV(r io.Reader) ([]rawWorkload, []ValidationIssue, error) {
+    reader := csv.NewReader(r)
+    reader.FieldsPerRecord = -1
+    header, err := reader.Read()
+    if err == io.EOF {
+        return nil, nil, nil
+    }
+    if err != nil {
+        return nil, nil, err
+    }
+    columns, err := csvColumns(header)
+    if err != nil {
+        return nil, nil, err
+    }
+    _, hasValueGenerated := columns["valuegenerated"]
+
+    builder := newRawBuilder()
+    for {
+        record, err := reader.Read()
+        if err == io.EOF {
+            break
+        }
+        if parseErr, ok := err.(*csv.ParseError); ok {
+            builder.rowIssue(parseErr.StartLine, "", parseErr.Err)
+            continue
+        }
+        if err != nil {
+            return nil, nil, err
+        }
+        line, _ := reader.FieldPos(0)
+        field := func(name string) string {
+            if i := columns[name]; i < len(record) {
+                return strings.TrimSpace(record[i])
+            }
+            return ""
+        }
+
+        point := rawTimedValue{Timestamp: field("timestamp"), Value: json.RawMessage(strconv.Quote(field("value")))}
+        workload := builder.add(line, field("workload"), field("dimension"), point)
+        if workload == nil || !hasValueGenerated {
+            continue
+        }
+        var valueGenerated *float64
+        if text := field("valuegenerated"); text != "" {
+            value, err := parseLoadValue(text)
+            if err != nil {
+                builder.rowIssue(line, workload.Name, fmt.Errorf("valueGenerated: %w", err))
+                continue
+            }
+            valueGenerated = &value
+        }
+        workload.declareValueGenerated(valueGenerated)
+    }
+    return builder.workloads, builder.issues, nil
+}
+
+// decodeRawJSONL decodes JSON Lines as written.
+func decodeRawJSONL(r io.Reader) ([]rawWorkload, []ValidationIssue, error) {
+    scanner := bufio.NewScanner(r)
+    scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+    builder := newRawBuilder()
+    for line := 1; scanner.Scan(); line++ {
+        text := []byte(strings.TrimSpace(scanner.Text()))
+        if len(text) == 0 {
+            continue
+        }
+
+        var fields map[string]json.RawMessage
+        if err := json.Unmarshal(text, &fields); err != nil {
+            builder.rowIssue(line, "", err)
+            continue
+        }
+        given, hasValueGenerated := lookupField(fields, "valueGenerated")
+        if _, isPoint := lookupField(fields, "dimension"); isPoint {
+            var point struct {
+                Timestamp string          `json:"timestamp"`
+                Workload  string          `json:"workload"`
+                Dimension string          `json:"dimension"`
+                Value     json.RawMessage `json:"value"`
+            }
+            if err := json.Unmarshal(text, &point); err != nil {
+                builder.rowIssue(line, "", err)
+                continue
+            }
+            workload := builder.add(line, point.Workload, point.Dimension, rawTimedValue{Timestamp: point.Timestamp, Value: point.Value})
+            if workload != nil && hasValueGenerated {
+                var valueGenerated *float64
+                if value, ok := parseRawValue(given); ok {
+                    valueGenerated = &value
+                }
+                workload.declareValueGenerated(valueGenerated)
+            }
+            continue
+        }
+
+        var added rawWorkload
+        if err := json.Unmarshal(text, &added); err != nil {
+            builder.rowIssue(line, "", err)
+            continue
+        }
+        workload := builder.workload(added.Name)
+        for dimension, load := range added.dimensions() {
+            workload.Loads[dimension] = append(workload.Loads[dimension], load...)
+        }
+        if hasValueGenerated {
+            workload.declareValueGenerated(added.ValueGenerated)
+        }
+    }
+    if err := scanner.Err(); err != nil {
+        return nil, nil, err
+    }
+    return builder.workloads, builder.issues, nil
+}
+
+// rawBuilder collects the rows of a long-format file into workloads as written, in the order the
+// workloads first appear, along with an issue for each row that couldn't be read.
+type rawBuilder struct {
+    workloads []rawWorkload
+    indexes   map[string]int
+    issues    []ValidationIssue
+}
+
+func newRawBuilder() *rawBuilder {
+    return &rawBuilder{indexes: make(map[string]int)}
+}
+
+// workload returns the workload named name, adding it if it's new. A workload needs no
+// valueGenerated until a row declares one for it.
+func (b *rawBuilder) workload(name string) *rawWorkload {
+    i, exists := b.indexes[name]
+    if !exists {
+        i = len(b.workloads)
+        b.indexes[name] = i
+        b.workloads = append(b.workloads, rawWorkload{Name: name, Loads: make(map[string][]rawTimedValue), optionalValueGenerated: true})
+    }
+    return &b.workloads[i]
+}
+
+// add appends one load value to its workload and returns the workload, or reports the row and
+// returns nil when it names no workload or dimension.
+func (b *rawBuilder) add(line int, name, dimension string, point rawTimedValue) *rawWorkload {
+    if name == "" {
+        b.rowIssue(line, "", errors.New("no workload given"))
+        return nil
+    }
+    if dimension == "" {
+        b.rowIssue(line, name, errors.New("no dimension given"))
+        return nil
+    }
+    workload := b.workload(name)
+    workload.Loads[dimension] = append(workload.Loads[dimension], point)
+    return workload
+}
+
+// rowIssue reports a row of the file that couldn't be read.
+func (b *rawBuilder) rowIssue(line int, workload string, err error) {
+    b.issues = append(b.issues, ValidationIssue{
+        Workload: workload,
+        Check:    CheckDecode,
+        Count:    1,
+        Message:  fmt.Sprintf("line %d: %v", line, err),
+    })
+}
+
+// declareValueGenerated records that a row gave the workload a valueGenerated column or field,
+// holding value or, when nil, nothing.
+func (w *rawWorkload) declareValueGenerated(value *float64) {
+    w.optionalValueGenerated = false
+    if value != nil {
+        w.ValueGenerated = value
+    }
+}
+
+// rawFromWorkload converts a decoded workload of a format without a valueGenerated back to the
+// form it would be written in.
+func rawFromWorkload(workload Workload) rawWorkload {
+    raw := rawWorkload{
+        Name:                   workload.Name,
+        Loads:                  make(map[string][]rawTimedValue, len(workload.Loads)),
+        optionalValueGenerated: true,
+    }
+    for dimension, load := range workload.Loads {
+        points := make([]rawTimedValue, len(load))
+        for i, timedValue := range load {
+            points[i] = rawTimedValue{
+                Timestamp: timedValue.Timestamp.Format(time.RFC3339Nano),
+                Value:     json.RawMessage(strconv.FormatFloat(timedValue.Value, 'g', -1, 64)),
+            }
+        }
+        raw.Loads[dimension] = points
+    }
+    return raw
+}
+
+// validateWorkload checks one workload, a dimension at a time in sorted order, and returns its
+// issues and the UTC offsets its timestamps use.
+func validateWorkload(filename string, raw rawWorkload, opts ValidationOptions) ([]ValidationIssue, timestampOffsets) {
+    var issues []ValidationIssue
+    issue := func(dimension, check string, count int, timestamp, message string) {
+        issues = append(issues, ValidationIssue{
+            File:      filename,
+            Workload:  raw.Name,
+            Dimension: dimension,
+            Check:     check,
+            Count:     count,
+            Timestamp: timestamp,
+            Message:   message,
+        })
+    }
+
+    if raw.ValueGenerated == nil && !raw.optionalValueGenerated {
+        issue("", CheckMissingValueGenerated, 1, "", "valueGenerated is missing or null")
+    }
+
+    loads := raw.dimensions()
+    dimensions := make([]string, 0, len(loads))
+    for dimension := range loads {
+        dimensions = append(dimensions, dimension)
+    }
+    sort.Strings(dimensions)
+
+    // Collect the parsed series for the length check shared with ValidateWorkloadSynchronization.
+    workload := Workload{Name: raw.Name, Loads: make(map[string][]TimedValue, len(loads))}
+    offsets := make(map[int]bool)
+    var firstOffset int
+    for _, dimension := range dimensions {
+        if err := CheckDimension(dimension); err != nil {
+            issue(dimension, CheckReservedDimension, 1, "", err.Error())
+        }
+        var bad, unsorted, duplicate, missing, negative checkCount
+        seen := make(map[int64]bool)
+        var previous time.Time
+        workload.Loads[dimension] = make([]TimedValue, 0, len(loads[dimension]))
+        for i, point := range loads[dimension] {
+            timestamp, err := time.Parse(time.RFC3339Nano, point.Timestamp)
+            if err != nil {
+                bad.add(point.Timestamp)
+                workload.Loads[dimension] = append(workload.Loads[dimension], TimedValue{})
+                continue
+            }
+            _, offset := timestamp.Zone()
+            if len(offsets) == 0 {
+                firstOffset = offset
+            }
+            offsets[offset] = true
+            if i > 0 && timestamp.Before(previous) {
+                unsorted.add(point.Timestamp)
+            }
+            if seen[timestamp.UnixNano()] {
+                duplicate.add(point.Timestamp)
+            }
+            seen[timestamp.UnixNano()] = true
+            previous = timestamp
+
+            value, ok := parseRawValue(point.Value)
+            switch {
+            case !ok:
+                missing.add(point.Timestamp)
+            case value < 0 && !opts.AllowNegative:
+                negative.add(point.Timestamp)
+            }
+            workload.Loads[dimension] = append(workload.Loads[dimension], TimedValue{Timestamp: timestamp, Value: value})
+        }
+        for _, c := range []struct {
+            count   checkCount
+            check   string
+            message string
+        }{
+            {bad, CheckBadTimestamp, "timestamps are not RFC 3339"},
+            {unsorted, CheckUnsortedTimestamps, "timestamps are earlier than the one before them"},
+            {duplicate, CheckDuplicateTimestamps, "timestamps appear more than once"},
+            {missing, CheckNaNValue, "values are missing, null or not a number"},
+            {negative, CheckNegativeValue, "values are negative"},
+        } {
+            if c.count.count > 0 {
+                issue(dimension, c.check, c.count.count, c.count.first, fmt.Sprintf("%d %s", c.count.count, c.message))
+            }
+        }
+    }
+
+    if err := ValidateWorkloadSynchronization(workload); err != nil {
+        lengths := make([]string, len(dimensions))
+        for i, dimension := range dimensions {
+            lengths[i] = fmt.Sprintf("%s=%d", dimension, len(workload.Loads[dimension]))
+        }
+        issue("", CheckLengthMismatch, len(dimensions), "", fmt.Sprintf("%v: %s", err, strings.Join(lengths, ", ")))
+    }
+
+    sortedOffsets := make([]int, 0, len(offsets))
+    for offset := range offsets {
+        sortedOffsets = append(sortedOffsets, offset)
+    }
+    sort.Ints(sortedOffsets)
+    return issues, timestampOffsets{first: firstOffset, used: sortedOffsets}
+}
+
+// checkCount counts the points failing a check and remembers the first one's timestamp.
+type checkCount struct {
+    count int
+    first string
+}
+
+func (c *checkCount) add(timestamp string) {
+    if c.count == 0 {
+        c.first = timestamp
+    }
+    c.count++
+}
+
+// parseRawValue returns a load value, or false when it is absent, null, NaN or not a number.
+// Values written as strings, as some collectors do for "NaN", are parsed too.
+func parseRawValue(raw json.RawMessage) (float64, bool) {
+    text := strings.TrimSpace(string(raw))
+    if text == "" || text == "null" {
+        return 0, false
+    }
+    if unquoted, err := strconv.Unquote(text); err == nil {
+        text = unquoted
+    }
+    value, err := strconv.ParseFloat(text, 64)
+    if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+        return 0, false
+    }
+    return value, true
+}
+
+// formatOffset formats a UTC offset in seconds as +hh:mm.
+func formatOffset(offset int) string {
+    sign := '+'
+    if offset < 0 {
+        sign, offset = '-', -offset
+    }
+    return fmt.Sprintf("%c%02d:%02d", sign, offset/3600, offset%3600/60)
+}
+
+// PrintValidationReport prints a summary line and each issue.
+func PrintValidationReport(report ValidationReport) {
+    fmt.Printf("Validated %d workloads in %d files: %d problems found\n", report.Workloads, report.Files, len(report.Issues))
+    for _, issue := range report.Issues {
+        location := issue.File
+        if issue.Workload != "" {
+            location += " " + issue.Workload
+        }
+        if issue.Dimension != "" {
+            location += " " + issue.Dimension
+        }
+        if issue.Timestamp != "" {
+            fmt.Printf("  %s: %s: %s (first at %s)\n", location, issue.Check, issue.Message, issue.Timestamp)
+        } else {
+            fmt.Printf("  %s: %s: %s\n", location, issue.Check, issue.Message)
+        }
+    }
+}
+
+// WriteValidationReportToFile writes the report as JSON.
+func WriteValidationReportToFile(report ValidationReport, outputFile string) error {
+    data, err := json.MarshalIndent(report, "", "    ")
+    if err != nil {
+        return err
+    }
+    return os.WriteFile(outputFile, data, 0o644)
+}
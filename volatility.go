@@ -0,0 +1,269 @@
+package laplace
+
+import (
+    "fmt"
+    "math"
+    "sort"
+    "strconv"
+    "time"
+)
+
+// CalculateRelativeVolatility returns the standard deviation of the interval averages for each load dimension.
+func CalculateRelativeVolatility(workload Workload, interval time.Duration) (map[string]float64, error) {
+    volatilities := make(map[string]float64, len(workload.Loads))
+    for dimension, load := range workload.Loads {
+        averages, err := calculateIntervalAverages(load, interval)
+        if err != nil {
+            return nil, fmt.Errorf("load %s: %w", dimension, err)
+        }
+        volatilities[dimension] = calculateStandardDeviation(averages)
+    }
+
+    return volatilities, nil
+}
+
+// DefaultVolatilityInterval is the window length used for volatility when none is configured.
+const DefaultVolatilityInterval = 5 * time.Minute
+
+// groupByInterval splits a chronologically ordered series into consecutive windows of the given
+// length, measured from the first timestamp. Windows with no values are skipped, so a gap in the
+// series doesn't shift the windows that follow it.
+func groupByInterval(timedValues []TimedValue, interval time.Duration) [][]TimedValue {
+    var groups [][]TimedValue
+    var windowStart time.Time
+
+    for i, timedValue := range timedValues {
+        elapsed := timedValue.Timestamp.Sub(windowStart)
+        if i == 0 {
+            windowStart = timedValue.Timestamp
+        } else if elapsed >= interval {
+            windowStart = windowStart.Add(elapsed / interval * interval)
+        } else {
+            groups[len(groups)-1] = append(groups[len(groups)-1], timedValue)
+            continue
+        }
+        groups = append(groups, []TimedValue{timedValue})
+    }
+    return groups
+}
+
+// combinedLoad returns the workload's load summed across every dimension at each of its
+// timestamps, in time order. A dimension with no value at a timestamp contributes nothing to it.
+func combinedLoad(workload Workload) []TimedValue {
+    totals := make(map[int64]*TimedValue)
+    var combined []*TimedValue
+    for _, dimension := range workload.Dimensions() {
+        for _, timedValue := range workload.Loads[dimension] {
+            key := timedValue.Timestamp.UnixNano()
+            total, exists := totals[key]
+            if !exists {
+                total = &TimedValue{Timestamp: timedValue.Timestamp}
+                totals[key] = total
+                combined = append(combined, total)
+            }
+            total.Value += timedValue.Value
+        }
+    }
+    sort.SliceStable(combined, func(i, j int) bool {
+        return combined[i].Timestamp.Before(combined[j].Timestamp)
+    })
+
+    series := make([]TimedValue, len(combined))
+    for i, total := range combined {
+        series[i] = *total
+    }
+    return series
+}
+
+func calculateIntervalAverages(timedValues []TimedValue, interval time.Duration) ([]float64, error) {
+    if len(timedValues) == 0 {
+        return nil, fmt.Errorf("timedValues is empty")
+    }
+    if interval <= 0 {
+        return nil, fmt.Errorf("interval must be positive, got %v", interval)
+    }
+
+    var intervalAverages []float64
+    for _, group := range groupByInterval(timedValues, interval) {
+        intervalAverages = append(intervalAverages, average(group))
+    }
+
+    return intervalAverages, nil
+}
+
+type WorkloadVolatility struct {
+    Name       string
+    Volatility float64
+}
+
+// calculateVolatilityOfRows returns the standard deviation of one numeric CSV column over rows.
+func calculateVolatilityOfRows(rows [][]string, column int) (float64, error) {
+    var values []float64
+
+    // Collect values for the interval
+    for _, row := range rows {
+        value, err := strconv.ParseFloat(row[column], 64)
+        if err != nil {
+            return 0.0, err
+        }
+        values = append(values, value)
+    }
+
+    return calculateStandardDeviation(values), nil
+}
+
+func calculateLoadSumInIntervals(workload Workload, interval time.Duration) ([]float64, error) {
+    if interval <= 0 {
+        return nil, fmt.Errorf("interval must be positive, got %v", interval)
+    }
+
+    var sums []float64
+    for _, group := range groupByInterval(combinedLoad(workload), interval) {
+        sums = append(sums, average(group)) // Average sum for this interval
+    }
+    return sums, nil
+}
+
+// calculateCombinedVolatility returns the standard deviation of the workload's interval-averaged
+// combined load, or 0 when the workload has no load.
+func calculateCombinedVolatility(workload Workload, interval time.Duration) float64 {
+    sums, err := calculateLoadSumInIntervals(workload, interval)
+    if err != nil || len(sums) == 0 {
+        return 0
+    }
+    return calculateVolatility(sums)
+}
+
+func calculateVolatility(sums []float64) float64 {
+    mean, variance := 0.0, 0.0
+    for _, sum := range sums {
+        mean += sum
+    }
+    mean /= float64(len(sums))
+
+    for _, sum := range sums {
+        variance += (sum - mean) * (sum - mean)
+    }
+    variance /= float64(len(sums))
+
+    return math.Sqrt(variance)
+}
+
+// calculateSeriesIntervalChanges returns the change in the summed value of a series between each
+// interval and the one before it, stamped with the last timestamp of the interval.
+func calculateSeriesIntervalChanges(series []TimedValue, interval time.Duration) ([]TimedValue, error) {
+    if interval <= 0 {
+        return nil, fmt.Errorf("interval must be positive, got %v", interval)
+    }
+
+    var intervalSums []TimedValue
+
+    var previousSum float64
+    for i, group := range groupByInterval(series, interval) {
+        total := sum(group)
+        timestamp := group[len(group)-1].Timestamp
+
+        // Calculate change from the previous interval
+        change := total - previousSum
+        if i != 0 { // Skip the first interval as it has no previous data
+            intervalSums = append(intervalSums, TimedValue{Timestamp: timestamp, Value: change})
+        }
+        previousSum = total
+    }
+
+    return intervalSums, nil
+}
+
+// Volatility metrics by which workloads can be ranked and tiered.
+const (
+    // StdDevMetric is the standard deviation of the interval averages, in units of load.
+    StdDevMetric = "stddev"
+    // CVMetric, the coefficient of variation, is StdDevMetric divided by the mean load.
+    CVMetric = "cv"
+    // PeakToMeanMetric is the highest load divided by the mean load.
+    PeakToMeanMetric = "peak-to-mean"
+    // IQRToMeanMetric is the interquartile range of the load divided by the mean load.
+    IQRToMeanMetric = "iqr-to-mean"
+)
+
+// NormalizedVolatility measures the volatility of a load series relative to its mean, so that
+// workloads of very different sizes can be compared. Every measure is 0 for a series whose mean
+// load isn't positive.
+type NormalizedVolatility struct {
+    CV         float64
+    PeakToMean float64
+    IQRToMean  float64
+}
+
+// calculateNormalizedVolatility divides the series' volatility, its highest value and its
+// interquartile range by its mean.
+func calculateNormalizedVolatility(values []float64, volatility float64) NormalizedVolatility {
+    if len(values) == 0 {
+        return NormalizedVolatility{}
+    }
+    average := mean(values)
+    if average <= 0 {
+        return NormalizedVolatility{}
+    }
+    sorted := append([]float64(nil), values...)
+    sort.Float64s(sorted)
+    return NormalizedVolatility{
+        CV:         volatility / average,
+        PeakToMean: sorted[len(sorted)-1] / average,
+        IQRToMean:  (nearestRank(sorted, 75) - nearestRank(sorted, 25)) / average,
+    }
+}
+
+// ValidateVolatilityMetric checks that metric names one of the volatility metrics.
+func ValidateVolatilityMetric(metric string) error {
+    switch metric {
+    case StdDevMetric, CVMetric, PeakToMeanMetric, IQRToMeanMetric:
+        return nil
+    }
+    return fmt.Errorf("unknown volatility metric %q: want %s, %s, %s or %s", metric, StdDevMetric, CVMetric, PeakToMeanMetric, IQRToMeanMetric)
+}
+
+// WorkloadVolatilityMetric returns the average across dimensions of one of the workload's
+// volatility metrics, as calculated by CalculateWorkloadStats.
+func WorkloadVolatilityMetric(workload Workload, metric string) float64 {
+    dimensions := make([]string, 0, len(workload.Volatilities))
+    for dimension := range workload.Volatilities {
+        dimensions = append(dimensions, dimension)
+    }
+    if len(dimensions) == 0 {
+        return 0
+    }
+    sort.Strings(dimensions)
+
+    var total float64
+    for _, dimension := range dimensions {
+        normalized := workload.NormalizedVolatilities[dimension]
+        switch metric {
+        case CVMetric:
+            total += normalized.CV
+        case PeakToMeanMetric:
+            total += normalized.PeakToMean
+        case IQRToMeanMetric:
+            total += normalized.IQRToMean
+        default:
+            total += workload.Volatilities[dimension]
+        }
+    }
+    return total / float64(len(dimensions))
+}
+
+// RankVolatility returns the workloads ordered from the most to the least volatile by metric.
+// Workloads with equal volatility keep their order.
+func RankVolatility(workloads []Workload, metric string) ([]WorkloadVolatility, error) {
+    if err := ValidateVolatilityMetric(metric); err != nil {
+        return nil, err
+    }
+    volatilities := make([]WorkloadVolatility, len(workloads))
+    for i, workload := range workloads {
+        volatilities[i] = WorkloadVolatility{Name: workload.Name, Volatility: WorkloadVolatilityMetric(workload, metric)}
+    }
+    sort.SliceStable(volatilities, func(i, j int) bool {
+        return volatilities[i].Volatility > volatilities[j].Volatility
+    })
+    return volatilities, nil
+}
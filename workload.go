@@ -0,0 +1,133 @@
+package laplace
+
+import (
+    "encoding/json"
+    "fmt"
+    "sort"
+    "time"
+)
+
+// Workload struct represents the data structure for a workload.
+// Loads holds one time series per named load dimension (e.g. "cpu", "net_egress").
+// Fields without a JSON name are derived by CalculateWorkloadStats and are not serialized.
+type Workload struct {
+    Name                  string    `json:"name"`
+    Loads                 map[string][]TimedValue `json:"loads"`
+    ValueGenerated        float64   `json:"valueGenerated"`
+    TotalLoads            map[string]float64 `json:"-"`
+    RelativeValueGenerated float64 `json:"-"`
+    RelativeLoads         map[string]float64 `json:"-"`
+    Costs                 map[string]float64 `json:"-"`
+    CostBreakdown         map[CostBucket]float64 `json:"-"`
+    Volatilities          map[string]float64 `json:"-"`
+    CombinedVolatility    float64 `json:"-"`
+    NormalizedVolatilities map[string]NormalizedVolatility `json:"-"`
+    VolatilityTier        string  `json:"-"`
+    Percentiles           map[string]Percentiles `json:"-"`
+    SynthesizedPoints     map[string]int `json:"-"`
+    TotalLoad             float64 `json:"-"`
+    TotalRelativeLoad     float64 `json:"-"`
+    RelativeCost          float64 `json:"-"`
+    TotalCost             float64 `json:"-"`
+    Timestamp             time.Time `json:"-"`
+}
+
+// UnmarshalJSON decodes a workload, accepting the legacy "load1", "load2" and "load3"
+// arrays as dimensions of the same name alongside the "loads" map.
+func (w *Workload) UnmarshalJSON(b []byte) error {
+    type workloadAlias Workload
+    aux := struct {
+        *workloadAlias
+        Load1 []TimedValue `json:"load1"`
+        Load2 []TimedValue `json:"load2"`
+        Load3 []TimedValue `json:"load3"`
+    }{workloadAlias: (*workloadAlias)(w)}
+
+    if err := json.Unmarshal(b, &aux); err != nil {
+        return err
+    }
+
+    legacy := map[string][]TimedValue{"load1": aux.Load1, "load2": aux.Load2, "load3": aux.Load3}
+    for dimension, load := range legacy {
+        if load == nil {
+            continue
+        }
+        if w.Loads == nil {
+            w.Loads = make(map[string][]TimedValue)
+        }
+        if _, exists := w.Loads[dimension]; !exists {
+            w.Loads[dimension] = load
+        }
+    }
+    for dimension := range w.Loads {
+        if err := CheckDimension(dimension); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// CheckDimension rejects a load dimension named TotalDimension, which would collide with the load
+// summed across every dimension in percentiles, peaks, correlations and capacity limits.
+func CheckDimension(dimension string) error {
+    if dimension == TotalDimension {
+        return fmt.Errorf("load dimension %q is reserved for the load summed across every dimension", dimension)
+    }
+    return nil
+}
+
+// Dimensions returns the names of the workload's load dimensions in sorted order.
+func (w Workload) Dimensions() []string {
+    dimensions := make([]string, 0, len(w.Loads))
+    for dimension := range w.Loads {
+        dimensions = append(dimensions, dimension)
+    }
+    sort.Strings(dimensions)
+    return dimensions
+}
+
+// CollectDimensions returns the sorted union of load dimensions declared by the workloads.
+func CollectDimensions(workloads []Workload) []string {
+    seen := make(map[string]bool)
+    var dimensions []string
+    for _, workload := range workloads {
+        for dimension := range workload.Loads {
+            if !seen[dimension] {
+                seen[dimension] = true
+                dimensions = append(dimensions, dimension)
+            }
+        }
+    }
+    sort.Strings(dimensions)
+    return dimensions
+}
+
+// Data struct is a container for a slice of Workloads
+type Data struct {
+    Workloads []Workload `json:"workloads"`
+}
+
+// TimedValue struct represents a value with an associated timestamp
+type TimedValue struct {
+    Timestamp time.Time `json:"timestamp"`
+    Value     float64   `json:"value"`
+}
+
+// SummedWorkload struct aggregates the total loads of each dimension for each timestamp
+type SummedWorkload struct {
+    Timestamp   time.Time
+    Totals      map[string]float64
+}
+
+func ValidateWorkloadSynchronization(workload Workload) error {
+    expected := -1
+    for _, load := range workload.Loads {
+        if expected == -1 {
+            expected = len(load)
+        }
+        if len(load) != expected {
+            return fmt.Errorf("workload '%s' has unsynchronized load arrays", workload.Name)
+        }
+    }
+    return nil
+}